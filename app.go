@@ -1,9 +1,12 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -11,8 +14,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 	"github.com/xuri/excelize/v2"
+	"golang.org/x/sync/semaphore"
 )
 
 // App struct
@@ -21,6 +26,20 @@ type App struct {
 	mu         sync.Mutex
 	progress   int
 	cancelFunc context.CancelFunc // 用于取消检查
+	run        int                // 当前检查的编号，每次开始检查时加一
+	opts       Options            // 检查选项
+	detail     ProgressDetail     // 本次检查的详细进度
+	stats      Stats              // 最近一次检查的统计
+	metrics    *metrics           // 设置 MetricsAddr 后创建，进程内共用
+
+	// metricsReg、metricsServer 和 metricsAddr 是指标的注册表、HTTP 服务及其监听地址，
+	// MetricsAddr 改变时据此换到新地址
+	metricsReg    *prometheus.Registry
+	metricsServer *http.Server
+	metricsAddr   string
+
+	// reporter 每完成一个 URL 调用一次，命令行模式用来打印进度
+	reporter func(completed, total int, r Result)
 }
 
 // NewApp creates a new App application struct
@@ -34,105 +53,509 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 }
 
-// Result 结构体，用于存储 URL 和文件大小
+// Result 结构体，用于存储 URL 和文件大小。JSON 字段名固定为 json 标签，不随字段改名变化
 type Result struct {
-	URL  string
-	Size string
+	URL         string    `json:"URL"`
+	Index       int       `json:"Index"` // 在输入列表中的位置（从 0 开始），排序后可据此还原输入顺序
+	Size        string    `json:"Size"`
+	Bytes       int64     `json:"Bytes"`       // 文件大小的原始字节数
+	StatusCode  int       `json:"StatusCode"`  // HTTP 状态码，请求未完成时为 0
+	ContentType string    `json:"ContentType"` // 响应的 Content-Type
+	ETag        string    `json:"ETag"`        // 响应的 ETag
+	FinalURL    string    `json:"FinalURL"`    // 跟随重定向后最终请求的 URL
+	Err         string    `json:"Err"`         // 失败原因，成功时为空
+	FailKind    string    `json:"FailKind"`    // 失败类型，如 timeout、dns、status，成功时为空，见 failureKind
+	CheckedAt   time.Time `json:"CheckedAt"`   // 检查完成的时间
+	Elapsed     Duration  `json:"Elapsed"`     // 检查耗时，含重试
+	Note        string    `json:"Note"`        // 附加说明，如“预算耗尽”
+	SizeMethod  string    `json:"SizeMethod"`  // 实际得到大小的请求方式：HEAD、OPTIONS、GET 或 Range
+	Proto       string    `json:"Proto"`       // 最后一次 HTTP 请求实际使用的协议版本，如 HTTP/1.1、HTTP/2.0
+	RemoteAddr  string    `json:"RemoteAddr"`  // 最后一次 HTTP 请求实际连接的 IP（经代理时为代理的 IP），没有建立连接时为空
+
+	SuspectSoftFail   bool `json:"SuspectSoftFail"`   // 疑似软失败：返回了很小的 HTML 页面（如登录页）而不是文件
+	CrossHostRedirect bool `json:"CrossHostRedirect"` // 重定向到了与原 URL 不同的主机
+	Truncated         bool `json:"Truncated"`         // 下载响应体时实际收到的字节数与 Content-Length 不一致
+	HitSizeCap        bool `json:"HitSizeCap"`        // 下载响应体时达到了 MaxBodyBytes，已停止读取
+	Insecure          bool `json:"Insecure"`          // 最终 URL（请求未完成时为输入的 URL）使用明文 HTTP
+	RedirectCount     int  `json:"RedirectCount"`     // 跟随的重定向次数
+
+	AcceptRanges       bool `json:"AcceptRanges"`       // 响应头声明了 Accept-Ranges: bytes
+	RangeActuallyWorks bool `json:"RangeActuallyWorks"` // 开启 Range 验证时，bytes=0-0 的请求确实返回了 206 和 1 字节内容
+
+	// 开启压缩比统计并下载响应体时，响应的 Content-Encoding、传输的字节数和解压后的字节数（即 Bytes），
+	// 压缩比为解压后 / 传输；响应未压缩或编码无法识别时只有传输字节数
+	ContentEncoding   string  `json:"ContentEncoding"`
+	TransferBytes     int64   `json:"TransferBytes"`
+	DecompressedBytes int64   `json:"DecompressedBytes"`
+	CompressionRatio  float64 `json:"CompressionRatio"`
+
+	// 设置 Repeat 时多次检查得到的最小、最大和出现次数最多的字节数，以及各次是否都成功且大小一致
+	MinBytes  int64 `json:"MinBytes"`
+	MaxBytes  int64 `json:"MaxBytes"`
+	ModeBytes int64 `json:"ModeBytes"`
+	Stable    bool  `json:"Stable"`
+
+	// 目标带预期大小时的校验结果：预期字节数、实际与预期之差和是否超出允许误差
+	ExpectedBytes int64 `json:"ExpectedBytes"`
+	SizeDelta     int64 `json:"SizeDelta"`
+	SizeMismatch  bool  `json:"SizeMismatch"`
+
+	// 回退链（auto 策略或 Strategies）中依次尝试的方式及各自的结果，如 head: 405、range: ok
+	Attempts []Attempt `json:"Attempts,omitempty"`
+
+	// 开启响应头记录时，最后一次请求的全部响应头，主要供 JSON 输出和库调用方排查问题使用
+	Headers map[string][]string `json:"Headers,omitempty"`
+
+	DupGroup   int `json:"DupGroup"`   // 疑似重复文件的组号，大小和 ETag 相同的结果组号相同，0 表示没有重复
+	FinalGroup int `json:"FinalGroup"` // 重定向到同一最终 URL 的输入 URL 的组号，0 表示没有其他输入到达同一地址
+
+	Alt         *Result `json:"Alt,omitempty"` // 开启协议对比时，另一协议（http/https 互换）的检查结果
+	SchemeMatch bool    `json:"SchemeMatch"`   // 两种协议都检查成功且大小一致
+}
+
+// Attempt 回退链中一种方式的尝试结果
+type Attempt struct {
+	Strategy   SizeStrategy `json:"Strategy"`   // 尝试的方式，如 head、range、get
+	StatusCode int          `json:"StatusCode"` // HTTP 状态码，请求未完成时为 0
+	Err        string       `json:"Err"`        // 失败原因，成功时为空
+}
+
+// String 返回简短的描述，如 head: 405、range: ok
+func (a Attempt) String() string {
+	switch {
+	case a.Err == "":
+		return string(a.Strategy) + ": ok"
+	case a.StatusCode != 0:
+		return fmt.Sprintf("%s: %d", a.Strategy, a.StatusCode)
+	default:
+		return fmt.Sprintf("%s: %s", a.Strategy, a.Err)
+	}
+}
+
+// Failed 判断检查是否失败
+func (r Result) Failed() bool {
+	return r.Err != ""
+}
+
+// fail 将结果标记为失败
+func (r *Result) fail(err error) {
+	r.Size = "获取失败"
+	r.Bytes = 0
+	r.Err = err.Error()
+	r.FailKind = failureKind(err)
+	var se *statusError
+	if errors.As(err, &se) {
+		r.StatusCode = se.code
+	}
+}
+
+// failedResult 构造失败的检查结果
+func failedResult(u string, err error) Result {
+	r := Result{URL: u}
+	r.fail(err)
+	return r
 }
 
-// CheckFileSizeConcurrent 并发检查 URL 文件大小
+// ProgressDetail 详细进度，随 progressDetail 事件发送给前端
+type ProgressDetail struct {
+	Completed int `json:"completed"` // 已完成（含失败）
+	Succeeded int `json:"succeeded"` // 成功
+	Failed    int `json:"failed"`    // 失败
+	Total     int `json:"total"`     // URL 总数
+}
+
+// CheckFileSizeConcurrent 并发检查 URL 文件大小。outputFile 为空时使用配置文件中的 outputFile，
+// 两者都为空时不写入任何文件，只返回结果，统计仍可通过 LastStats 获取
 func (a *App) CheckFileSizeConcurrent(urls []string, concurrency int, outputFile string) ([]Result, error) {
-	// 动态获取当前用户的桌面路径
-	homeDir, err := os.UserHomeDir()
+	return a.checkTargetsContext(context.Background(), targetsFromURLs(urls), concurrency, outputFile)
+}
+
+// CheckTargets 并发检查带单独设置（如超时）的目标，参数含义同 CheckFileSizeConcurrent
+func (a *App) CheckTargets(targets []Target, concurrency int, outputFile string) ([]Result, error) {
+	return a.checkTargetsContext(context.Background(), targets, concurrency, outputFile)
+}
+
+// checkTargetsContext 同 CheckTargets，ctx 被取消或到达截止时间时与调用 CancelCheck 一样停止检查，
+// 供命令行和库调用方接入自己的生命周期。不导出，以免被 Wails 绑定到前端（前端无法传入 context）
+func (a *App) checkTargetsContext(ctx context.Context, targets []Target, concurrency int, outputFile string) ([]Result, error) {
+	// 显式传入的参数优先于配置文件
+	opts := a.options()
+	if concurrency > 0 {
+		opts.Concurrency = concurrency
+	}
+	if outputFile != "" {
+		opts.OutputFile = outputFile
+	}
+
+	// 复制一份再记录输入位置，不修改调用方的切片
+	indexed := make([]Target, len(targets))
+	for i, t := range targets {
+		t.index = i
+		indexed[i] = t
+	}
+	return a.check(ctx, indexed, opts)
+}
+
+// RecheckFailed 只重新检查 results 中失败的 URL，成功的结果原样保留，合并后重新排序并写入输出文件。
+// 适合网络不稳定时在大批量检查之后补查，比全部重新检查便宜得多。没有失败的结果时原样返回
+func (a *App) RecheckFailed(results []Result, concurrency int, outputFile string) ([]Result, error) {
+	opts := a.options()
+	// 补查不使用检查点：它只覆盖失败的 URL，重新打开会清空原检查点，之后续跑就要重查全部 URL
+	opts.CheckpointFile, opts.Resume = "", false
+	if concurrency > 0 {
+		opts.Concurrency = concurrency
+	}
+	if outputFile != "" {
+		opts.OutputFile = outputFile
+	}
+
+	var kept []Result
+	var failed []Target
+	for _, r := range results {
+		if r.Failed() {
+			failed = append(failed, Target{URL: r.URL, index: r.Index})
+		} else {
+			kept = append(kept, r)
+		}
+	}
+	if len(failed) == 0 {
+		return results, nil
+	}
+	return a.checkWithPrior(context.Background(), failed, kept, opts)
+}
+
+// check 按选项并发检查 URL 文件大小。parent 被取消或调用 CancelCheck 时停止派发新请求，
+// 已得到的部分结果仍会写入输出文件，同时返回部分结果和取消原因。
+// 成功完成时发送 done 事件（输出路径和统计），失败时发送 error 事件
+func (a *App) check(parent context.Context, targets []Target, opts Options) ([]Result, error) {
+	// 低内存模式不保留结果，只返回错误，统计通过 LastStats 获取
+	if opts.LowMemory {
+		return nil, a.checkLowMemory(parent, sliceScanner(targets), opts)
+	}
+	return a.checkWithPrior(parent, targets, nil, opts)
+}
+
+// checkWithPrior 同 check，prior 为已有的结果，与本次检查的结果合并后一起排序、写入和统计
+func (a *App) checkWithPrior(parent context.Context, targets []Target, prior []Result, opts Options) (results []Result, err error) {
+	start := time.Now()
+	var outputPath string
+	var stats Stats
+	defer func() {
+		if err != nil {
+			a.emit("error", ErrorEvent{Kind: errorKind(err), Message: err.Error(), Stats: stats})
+		}
+		a.notifyWebhook(opts, outputPath, stats, err)
+	}()
+
+	if len(targets) == 0 {
+		return nil, ErrNoURLs
+	}
+
+	if opts, err = a.prepareOptions(opts); err != nil {
+		return nil, err
+	}
+	if targets = shardTargets(targets, opts); len(targets) == 0 {
+		return nil, ErrNoURLs
+	}
+
+	// 没有输出文件时只返回结果，不写入文件
+	if opts.OutputFile != "" {
+		if outputPath, err = resolveOutputPath(opts.OutputFile); err != nil {
+			return nil, err
+		}
+	}
+
+	// 续跑时跳过检查点中已完成的 URL，最后与之前的结果合并
+	var cp *checkpoint
+	if opts.CheckpointFile != "" {
+		checkpointPath, err := resolveOutputPath(opts.CheckpointFile)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Resume {
+			done, err := readCheckpoint(checkpointPath)
+			if err != nil {
+				return nil, err
+			}
+			targets = remainingTargets(targets, done, opts.CanonicalSortQuery)
+			prior = append(prior, done...)
+		}
+		if cp, err = openCheckpoint(checkpointPath, opts.Resume); err != nil {
+			return nil, err
+		}
+		defer cp.Close()
+	}
+
+	// 创建 HTTP 客户端
+	c, err := newChecker(opts)
 	if err != nil {
-		return nil, fmt.Errorf("获取用户主目录失败: %w", err)
+		return nil, err
+	}
+	if opts.MetricsAddr != "" {
+		if c.metrics, err = a.startMetrics(opts.MetricsAddr); err != nil {
+			return nil, err
+		}
 	}
-	outputPath := filepath.Join(homeDir, "Desktop", outputFile)
 
 	// 创建可取消的 context
-	ctx, cancel := context.WithCancel(context.Background())
-	a.cancelFunc = cancel // 保存取消函数
-	defer cancel()        // 确保检查完成后释放资源
+	ctx, cancel, run := a.startRun(parent, len(targets))
+	defer cancel() // 确保检查完成后释放资源
+
+	pw := newPartialWriter(prior, outputPath, opts, start, a.warn)
+	results = a.runTargets(ctx, run, c, cp, pw, targets, len(targets))
+	pw.wait()
+	cancelErr := ctx.Err()
+
+	results = append(prior, results...)
+	if !opts.SkipSort {
+		sortResults(results, opts.SortBy, opts.SortOrder)
+	}
+	if opts.DuplicateCheck {
+		markDuplicates(results)
+	}
+	if opts.SameFinalCheck {
+		markSameFinal(results, opts.CanonicalSortQuery)
+	}
+
+	// 按类型汇总始终基于全部结果
+	var byType []TypeSummary
+	if opts.TypeSummary {
+		byType = summarizeByType(results)
+	}
+	var byHost []HostSummary
+	if opts.HostSummary {
+		byHost = summarizeByHost(results)
+	}
+
+	stats = computeStats(results, time.Since(start))
+	stats.StartedAt = start
+	stats.EstimatedDownload = estimateDownload(stats.TotalBytes, opts.AssumedBandwidth)
+	stats.Requests = c.requests.Load()
+	stats.BytesRead = c.bodyBytes.Load()
+	stats.Retries = c.retries.Load()
+	stats.TrippedHosts = c.breaker.trippedHosts()
+
+	// 写入输出文件，过滤只影响写入的内容，返回值和统计仍基于全部结果
+	if outputPath != "" {
+		if err := writeOutput(topResults(filterResults(results, opts.OutputFilter), opts.TopN), stats, byType, byHost, outputPath, opts); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrWriteOutput, err)
+		}
+	}
+
+	a.mu.Lock()
+	a.stats = stats
+	a.mu.Unlock()
+
+	if cancelErr != nil {
+		return results, fmt.Errorf("%w: %w", ErrCancelled, cancelErr)
+	}
+	if cp != nil {
+		if err := cp.Close(); err != nil {
+			return results, fmt.Errorf("%w: %w", ErrWriteOutput, err)
+		}
+	}
+
+	a.emit("done", DoneEvent{OutputPath: outputPath, Stats: stats})
+	return results, nil
+}
+
+// prepareOptions 填充默认值并校验选项，并发数超过上限时按上限处理并发出警告
+func (a *App) prepareOptions(opts Options) (Options, error) {
+	opts = opts.withDefaults()
+	if err := opts.validate(); err != nil {
+		return opts, fmt.Errorf("%w: %w", ErrInvalidOptions, err)
+	}
 
+	requested := opts.Concurrency
+	opts, clamped := opts.clampConcurrency()
+	if clamped {
+		a.warn(fmt.Sprintf("并发数 %d 超过上限 %d，已按 %d 处理", requested, opts.MaxConcurrency, opts.Concurrency))
+	}
+	if opts, clamped = opts.clampOpenFiles(); clamped {
+		a.warn(fmt.Sprintf("按文件描述符预算 %d，并发数限制为 %d，空闲连接数限制为 %d", opts.MaxOpenFiles, opts.Concurrency, opts.MaxIdleConns))
+	}
+	return opts, nil
+}
+
+// warn 输出警告：写入标准错误，并通过 warning 事件通知前端
+func (a *App) warn(msg string) {
+	log.Println("警告:", msg)
+	a.emit("warning", msg)
+}
+
+// runTargets 按并发数检查一组目标，返回与 targets 一一对应的结果，total 为进度显示的总数。
+// ctx 被取消时停止派发，达到请求数上限时同样停止，未派发的目标记为相应的失败
+func (a *App) runTargets(ctx context.Context, run int, c *checker, cp *checkpoint, pw *partialWriter, targets []Target, total int) []Result {
 	var wg sync.WaitGroup
-	results := make([]Result, len(urls))
-	queue := make(chan int, concurrency) // 控制并发数
-
-	// 创建 HTTP 客户端，设置超时时间
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	for i, url := range urls {
-		select {
-		case <-ctx.Done(): // 监听取消信号
-			return nil, ctx.Err()
-		default:
-			wg.Add(1)
-			queue <- i // 占用一个并发槽
-			go func(index int, u string) {
-				defer wg.Done()
-				defer func() { <-queue }() // 释放并发槽
-
-				size, err := getFileSize(ctx, client, u) // 传递 context 和 client
-				if err != nil {
-					results[index] = Result{URL: u, Size: "获取失败"}
-				} else {
-					results[index] = Result{URL: u, Size: formatFileSize(size)}
-				}
+	results := make([]Result, len(targets))
+	// 控制并发数，每个 worker 占一个槽，按大小加权时下载大文件会临时多占
+	sem := semaphore.NewWeighted(int64(c.opts.Concurrency))
+
+	var sc SizeChecker = c
+	if c.opts.Checker != nil {
+		sc = c.opts.Checker
+	}
 
-				// 更新进度
-				a.mu.Lock()
-				a.progress = (index + 1) * 100 / len(urls)
-				runtime.EventsEmit(a.ctx, "progress", a.progress)
-				a.mu.Unlock()
-			}(i, url)
+dispatch:
+	for i, target := range targets {
+		// 占用一个并发槽；所有槽都被慢请求占住时也能及时响应取消
+		if err := sem.Acquire(ctx, 1); err != nil {
+			break dispatch
+		}
+		// 达到请求数上限后不再派发，已在进行的请求（含重试）仍会完成
+		if c.requestLimitReached() {
+			sem.Release(1)
+			break dispatch
 		}
+
+		wg.Add(1)
+		s := &slot{sem: sem, held: 1}
+		go func(index int, t Target) {
+			defer wg.Done()
+			defer s.release() // 释放并发槽
+
+			results[index] = checkTarget(withSlot(ctx, s), sc, t)
+			results[index].Index = t.index
+			// 因取消而失败的 URL 不算完成，续跑时需要重新检查
+			if cp != nil && ctx.Err() == nil {
+				cp.record(results[index])
+			}
+			pw.add(results[index])
+			if c.opts.OnResult != nil {
+				c.opts.OnResult(results[index])
+			}
+			a.updateProgress(run, total, results[index])
+		}(i, target)
 	}
 
 	wg.Wait()
 
-	// 按文件大小倒序排序
-	sort.Slice(results, func(i, j int) bool {
-		sizeI := parseSize(results[i].Size)
-		sizeJ := parseSize(results[j].Size)
-		return sizeI > sizeJ
+	// 取消或达到请求数上限时尚未派发的 URL 同样记为失败，保证结果与输入一一对应
+	cancelErr := ctx.Err()
+	for i, r := range results {
+		if r.URL != "" {
+			continue
+		}
+		if cancelErr != nil {
+			results[i] = failedResult(targets[i].URL, cancelErr)
+		} else {
+			results[i] = failedResult(targets[i].URL, errRequestLimit)
+		}
+		results[i].Index = targets[i].index
+	}
+	return results
+}
+
+// sortResults 按 by 指定的列排序，失败的结果总是排在最后；值相同时按 URL 字母顺序，
+// 保证每次输出的顺序一致。order 为空时按大小排序为倒序，按其他列排序为正序
+func sortResults(results []Result, by SortColumn, order SortOrder) {
+	desc := order == SortDesc || (order == SortDefault && by == SortBySize)
+	sort.SliceStable(results, func(i, j int) bool {
+		// 取指针避免每次比较复制整个结果
+		ri, rj := &results[i], &results[j]
+		if ri.Failed() != rj.Failed() {
+			return rj.Failed()
+		}
+		if c := compareResults(ri, rj, by); c != 0 {
+			return (c > 0) == desc
+		}
+		return ri.URL < rj.URL
 	})
+}
 
-	// 写入 Excel 文件
-	if err := writeToExcel(results, outputPath); err != nil {
-		return nil, err
+// compareResults 按指定列比较两个结果
+func compareResults(a, b *Result, by SortColumn) int {
+	switch by {
+	case SortByURL:
+		return cmp.Compare(a.URL, b.URL)
+	case SortByStatus:
+		return cmp.Compare(a.StatusCode, b.StatusCode)
+	case SortByContentType:
+		return cmp.Compare(a.ContentType, b.ContentType)
+	case SortByDuration:
+		return cmp.Compare(a.Elapsed, b.Elapsed)
+	default:
+		return cmp.Compare(a.Bytes, b.Bytes)
 	}
-
-	return results, nil
 }
 
-// getFileSize 获取指定 URL 文件的大小，支持 context 取消
-func getFileSize(ctx context.Context, client *http.Client, url string) (int64, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
-	if err != nil {
-		return 0, err
+// resolveOutputPath 解析输出文件路径，相对路径放在当前用户的桌面目录下
+func resolveOutputPath(outputFile string) (string, error) {
+	if filepath.IsAbs(outputFile) {
+		return outputFile, nil
 	}
 
-	resp, err := client.Do(req)
+	// 动态获取当前用户的桌面路径
+	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return 0, err
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	return filepath.Join(homeDir, "Desktop", outputFile), nil
+}
+
+// startRun 开始新的一次检查：取消仍在进行的上一次检查，重置进度，返回本次检查的 context 和编号。
+// 连续发起检查时以最后一次为准，上一次检查中仍在进行的请求完成后不再更新进度
+func (a *App) startRun(parent context.Context, total int) (context.Context, context.CancelFunc, int) {
+	ctx, cancel := context.WithCancel(parent)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancelFunc != nil {
+		a.cancelFunc()
+	}
+	a.cancelFunc = cancel
+	a.run++
+	a.detail = ProgressDetail{Total: total}
+	a.progress = 0
+	return ctx, cancel, a.run
+}
+
+// updateProgress 记录一个 URL 检查完成，更新进度并通知前端。run 不是当前检查时忽略
+func (a *App) updateProgress(run, total int, r Result) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if run != a.run {
+		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("HTTP 状态码: %d", resp.StatusCode)
+	a.detail.Completed++
+	if r.Failed() {
+		a.detail.Failed++
+	} else {
+		a.detail.Succeeded++
 	}
+	a.progress = a.detail.Completed * 100 / total
 
-	size := resp.ContentLength
-	if size <= 0 {
-		return 0, errors.New("无法确定文件大小")
+	// progress 事件保持只发送百分比，兼容已有前端；progressDetail 携带完整计数
+	a.emit("progress", a.progress)
+	a.emit("progressDetail", a.detail)
+	if a.reporter != nil {
+		a.reporter(a.detail.Completed, total, r)
 	}
+}
 
-	return size, nil
+// Progress 返回当前检查的进度百分比，供轮询方式的调用方使用
+func (a *App) Progress() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.progress
+}
+
+// emit 向前端发送事件，命令行模式下没有 Wails 上下文，直接忽略
+func (a *App) emit(name string, data ...interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, name, data...)
 }
 
 // formatFileSize 格式化文件大小为易读的字符串
 func formatFileSize(size int64) string {
 	switch {
+	case size >= 1<<50:
+		return fmt.Sprintf("%.2f PB", float64(size)/(1<<50))
+	case size >= 1<<40:
+		return fmt.Sprintf("%.2f TB", float64(size)/(1<<40))
 	case size >= 1<<30:
 		return fmt.Sprintf("%.2f GB", float64(size)/(1<<30))
 	case size >= 1<<20:
@@ -144,6 +567,16 @@ func formatFileSize(size int64) string {
 	}
 }
 
+// sizeUnits 各大小单位对应的字节数
+var sizeUnits = map[string]int64{"B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40, "PB": 1 << 50}
+
+// fileSizeIn 将字节数换算为固定单位的数值，四舍五入保留 precision 位小数，如 fileSizeIn(1536, "KB", 2) 为 1.5。
+// 与 formatFileSize 不同，返回不带单位的数字，便于在表格中求和。unit 应已校验过
+func fileSizeIn(size int64, unit string, precision int) float64 {
+	scale := math.Pow10(precision)
+	return math.Round(float64(size)/float64(sizeUnits[unit])*scale) / scale
+}
+
 // parseSize 将格式化后的文件大小字符串解析为字节数
 func parseSize(sizeStr string) int64 {
 	if sizeStr == "获取失败" {
@@ -154,6 +587,10 @@ func parseSize(sizeStr string) int64 {
 	fmt.Sscanf(sizeStr, "%f %s", &size, &unit)
 
 	switch unit {
+	case "PB":
+		return int64(size * (1 << 50))
+	case "TB":
+		return int64(size * (1 << 40))
 	case "GB":
 		return int64(size * (1 << 30))
 	case "MB":
@@ -167,18 +604,135 @@ func parseSize(sizeStr string) int64 {
 	}
 }
 
-// writeToExcel 将结果写入 Excel 文件
-func writeToExcel(results []Result, outputPath string) error {
-	excel := excelize.NewFile()
-	sheetName := "Results"
-	excel.SetSheetName(excel.GetSheetName(0), sheetName)
-	excel.SetCellValue(sheetName, "A1", "URL")
-	excel.SetCellValue(sheetName, "B1", "文件大小")
+// filterResults 按输出过滤方式筛选结果
+func filterResults(results []Result, filter OutputFilter) []Result {
+	if filter == FilterAll {
+		return results
+	}
+
+	filtered := make([]Result, 0, len(results))
+	for _, r := range results {
+		if r.Failed() == (filter == FilterFailuresOnly) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// topResults 返回字节数最大的 n 个结果，失败的结果排在成功的之后，保持 results 原有的顺序。
+// n 为 0 或结果不超过 n 个时原样返回
+func topResults(results []Result, n int) []Result {
+	if n <= 0 || len(results) <= n {
+		return results
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		ri, rj := &results[order[i]], &results[order[j]]
+		if ri.Failed() != rj.Failed() {
+			return rj.Failed()
+		}
+		return ri.Bytes > rj.Bytes
+	})
+
+	keep := make([]bool, len(results))
+	for _, i := range order[:n] {
+		keep[i] = true
+	}
+	top := make([]Result, 0, n)
+	for i, r := range results {
+		if keep[i] {
+			top = append(top, r)
+		}
+	}
+	return top
+}
+
+// writeToExcel 将结果按列写入 Excel 文件，byType、byHost 不为空时分别附加按类型、按主机汇总的工作表。
+// 设置了 ExcelTemplate 时在模板的当前工作表中从 ExcelStartRow/ExcelStartCol 开始写入，
+// 保留模板中的其他内容；ExcelHyperlinks 为 true 时 URL 单元格同时设为可点击的超链接
+func writeToExcel(results []Result, columns []column, stats Stats, byType []TypeSummary, byHost []HostSummary, outputPath string, opts Options) error {
+	var excel *excelize.File
+	var sheetName string
+	if opts.ExcelTemplate != "" {
+		var err error
+		if excel, err = excelize.OpenFile(opts.ExcelTemplate); err != nil {
+			return fmt.Errorf("打开 Excel 模板失败: %w", err)
+		}
+		sheetName = excel.GetSheetName(excel.GetActiveSheetIndex())
+	} else {
+		excel = excelize.NewFile()
+		sheetName = "Results"
+		excel.SetSheetName(excel.GetSheetName(0), sheetName)
+	}
+	defer excel.Close()
+
+	startRow, startCol := opts.ExcelStartRow, opts.ExcelStartCol
+	for j, col := range columns {
+		cell, _ := excelize.CoordinatesToCellName(startCol+j, startRow)
+		excel.SetCellValue(sheetName, cell, col.header)
+	}
 
+	hyperlinks := opts.ExcelHyperlinks
 	for i, result := range results {
-		row := i + 2
-		excel.SetCellValue(sheetName, fmt.Sprintf("A%d", row), result.URL)
-		excel.SetCellValue(sheetName, fmt.Sprintf("B%d", row), result.Size)
+		row := startRow + i + 1
+		for j, col := range columns {
+			cell, _ := excelize.CoordinatesToCellName(startCol+j, row)
+			if err := setExcelCell(excel, sheetName, cell, col.value(result)); err != nil {
+				return err
+			}
+			if hyperlinks && col.header == "URL" {
+				err := excel.SetCellHyperLink(sheetName, cell, result.URL, "External")
+				// 超出单个工作表的超链接数量上限后，其余 URL 只写文本
+				if errors.Is(err, excelize.ErrTotalSheetHyperlinks) {
+					hyperlinks = false
+				} else if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// 字节数列设置千位分组的数字格式，单元格中仍是数字，由 Excel 负责显示。
+	// 只设置数据所在的单元格，不影响模板中同一列的其他内容
+	format := "#,##0"
+	bytesStyle, err := excel.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		return err
+	}
+	for j, col := range columns {
+		if col.header != "字节数" || len(results) == 0 {
+			continue
+		}
+		first, _ := excelize.CoordinatesToCellName(startCol+j, startRow+1)
+		last, _ := excelize.CoordinatesToCellName(startCol+j, startRow+len(results))
+		if err := excel.SetCellStyle(sheetName, first, last, bytesStyle); err != nil {
+			return err
+		}
+	}
+
+	if len(byType) > 0 {
+		if err := writeTypeSheet(excel, byType); err != nil {
+			return err
+		}
+	}
+	if len(byHost) > 0 {
+		if err := writeHostSheet(excel, byHost); err != nil {
+			return err
+		}
+	}
+	if opts.SizeChart {
+		if err := writeSizeChartSheet(excel, results); err != nil {
+			return err
+		}
+	}
+	if opts.RunInfoSheet {
+		if err := writeRunInfoSheet(excel, stats, opts); err != nil {
+			return err
+		}
 	}
 
 	if err := excel.SaveAs(outputPath); err != nil {
@@ -188,8 +742,24 @@ func writeToExcel(results []Result, outputPath string) error {
 	return nil
 }
 
+// setExcelCell 按值的类型显式写入单元格：文本（如“1.50 MB”）写为字符串，不让 Excel 自行识别，
+// 整数（如字节数）写为数字
+func setExcelCell(excel *excelize.File, sheet, cell string, v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		return excel.SetCellStr(sheet, cell, v)
+	case int:
+		return excel.SetCellInt(sheet, cell, v)
+	default:
+		// int64 等其他数字由 SetCellValue 按数字写入
+		return excel.SetCellValue(sheet, cell, v)
+	}
+}
+
 // CancelCheck 取消检查
 func (a *App) CancelCheck() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if a.cancelFunc != nil {
 		a.cancelFunc() // 调用取消函数
 	}
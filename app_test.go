@@ -0,0 +1,421 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// newSizeServer 启动测试用的服务器：/size/N 返回 Content-Length 为 N 的空响应，
+// /missing 返回 404，/hang 一直不响应直到请求被取消
+func newSizeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/size/"):
+			w.Header().Set("Content-Length", strings.TrimPrefix(r.URL.Path, "/size/"))
+		case r.URL.Path == "/hang":
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheckTargetsContextCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	a := NewApp()
+	results, err := a.checkTargetsContext(ctx, targetsFromURLs([]string{srv.URL + "/a", srv.URL + "/b"}), 1, "")
+	if !errors.Is(err, ErrCancelled) {
+		t.Fatalf("err = %v，应为 ErrCancelled", err)
+	}
+	if len(results) != 2 || !results[0].Failed() || !results[1].Failed() {
+		t.Fatalf("取消后应返回两条失败结果: %+v", results)
+	}
+}
+
+func TestFormatAndParseSize(t *testing.T) {
+	tests := []struct {
+		size      int64
+		formatted string
+		parsed    int64 // 解析回来的字节数，保留两位小数会损失精度
+	}{
+		{0, "0 B", 0},
+		{1023, "1023 B", 1023},
+		{1024, "1.00 KB", 1024},
+		{1536, "1.50 KB", 1536},
+		{1<<20 - 1, "1024.00 KB", 1 << 20},
+		{1 << 20, "1.00 MB", 1 << 20},
+		{1<<20 + 1, "1.00 MB", 1 << 20},
+		{3 << 29, "1.50 GB", 3 << 29},
+		{1<<40 - 1, "1024.00 GB", 1 << 40},
+		{1 << 40, "1.00 TB", 1 << 40},
+		{5 << 49, "2.50 PB", 5 << 49},
+		{1 << 62, "4096.00 PB", 1 << 62},
+	}
+	for _, tt := range tests {
+		got := formatFileSize(tt.size)
+		if got != tt.formatted {
+			t.Errorf("formatFileSize(%d) = %q，应为 %q", tt.size, got, tt.formatted)
+		}
+		if back := parseSize(got); back != tt.parsed {
+			t.Errorf("parseSize(%q) = %d，应为 %d", got, back, tt.parsed)
+		}
+	}
+}
+
+func TestParseSizeMalformed(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"获取失败", -1},
+		{"", 0},
+		{"abc", 0},
+		{"12", 0},
+		{"MB", 0},
+		{"12 XB", 0},
+		{"1.5 mb", 0},
+	}
+	for _, tt := range tests {
+		if got := parseSize(tt.in); got != tt.want {
+			t.Errorf("parseSize(%q) = %d，应为 %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckFileSizeConcurrent(t *testing.T) {
+	srv := newSizeServer(t)
+	urls := []string{
+		srv.URL + "/size/2048",
+		srv.URL + "/missing",
+		srv.URL + "/size/3000000",
+		srv.URL + "/hang",
+		srv.URL + "/size/0",
+	}
+	out := filepath.Join(t.TempDir(), "out.xlsx")
+
+	a := NewApp()
+	a.SetOptions(Options{Timeout: Duration(200 * time.Millisecond)})
+	results, err := a.CheckFileSizeConcurrent(urls, 3, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 成功的按大小倒序，失败的排在最后并按 URL 排序
+	want := []struct {
+		url      string
+		size     string
+		bytes    int64
+		failKind string
+	}{
+		{srv.URL + "/size/3000000", "2.86 MB", 3000000, ""},
+		{srv.URL + "/size/2048", "2.00 KB", 2048, ""},
+		{srv.URL + "/size/0", "0 B", 0, ""},
+		{srv.URL + "/hang", "获取失败", 0, "timeout"},
+		{srv.URL + "/missing", "获取失败", 0, "status"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("得到 %d 条结果，应为 %d 条", len(results), len(want))
+	}
+	for i, w := range want {
+		r := results[i]
+		if r.URL != w.url || r.Size != w.size || r.Bytes != w.bytes || r.FailKind != w.failKind {
+			t.Errorf("第 %d 条 = {%s %s %d %q}，应为 %+v", i, r.URL, r.Size, r.Bytes, r.FailKind, w)
+		}
+	}
+	if results[4].StatusCode != http.StatusNotFound {
+		t.Errorf("404 的状态码记为 %d", results[4].StatusCode)
+	}
+	if p := a.Progress(); p != 100 {
+		t.Errorf("Progress() = %d，应为 100", p)
+	}
+	if stats := a.LastStats(); stats.Succeeded != 3 || stats.Failed != 2 || stats.TotalBytes != 3002048 {
+		t.Errorf("LastStats() = %+v", stats)
+	}
+
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(want)+1 || rows[0][0] != "URL" || rows[0][1] != "文件大小" {
+		t.Fatalf("工作表内容不符: %v", rows)
+	}
+	for i, w := range want {
+		if rows[i+1][0] != w.url || rows[i+1][1] != w.size {
+			t.Errorf("第 %d 行 = %v，应为 [%s %s]", i+2, rows[i+1], w.url, w.size)
+		}
+	}
+}
+
+func TestBackToBackRuns(t *testing.T) {
+	srv := newSizeServer(t)
+	a := NewApp()
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/hang", srv.URL + "/hang", srv.URL + "/hang"}, 1, "")
+		first <- err
+	}()
+	// 等第一次检查开始后立即发起第二次
+	for started := false; !started; {
+		time.Sleep(time.Millisecond)
+		a.mu.Lock()
+		started = a.run == 1
+		a.mu.Unlock()
+	}
+
+	results, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/size/1", srv.URL + "/size/2"}, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Bytes != 2 || results[1].Bytes != 1 {
+		t.Fatalf("第二次检查的结果不符: %+v", results)
+	}
+	if err := <-first; !errors.Is(err, ErrCancelled) {
+		t.Fatalf("第一次检查 err = %v，应为 ErrCancelled", err)
+	}
+
+	// 第一次检查被取消后完成的请求不能覆盖第二次的进度
+	a.mu.Lock()
+	detail := a.detail
+	a.mu.Unlock()
+	if p := a.Progress(); p != 100 || detail != (ProgressDetail{Completed: 2, Succeeded: 2, Total: 2}) {
+		t.Fatalf("Progress() = %d, detail = %+v", p, detail)
+	}
+}
+
+// writeTestWorkbook 写入一个含文件大小和字节数列的工作簿并重新打开
+func writeTestWorkbook(t *testing.T) *excelize.File {
+	t.Helper()
+	opts := Options{BytesColumn: true}.withDefaults()
+	results := []Result{
+		{URL: "http://example.com/a", Size: formatFileSize(3000000), Bytes: 3000000},
+		{URL: "http://example.com/b", Size: formatFileSize(1536), Bytes: 1536},
+	}
+	out := filepath.Join(t.TempDir(), "out.xlsx")
+	if err := writeToExcel(results, outputColumns(opts), Stats{}, nil, nil, out, opts); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if header, _ := f.GetCellValue("Results", "C1"); header != "字节数" {
+		t.Fatalf("C1 = %q，应为字节数列", header)
+	}
+	return f
+}
+
+func TestExcelCellTypes(t *testing.T) {
+	f := writeTestWorkbook(t)
+	for _, cell := range []string{"B2", "B3"} {
+		typ, err := f.GetCellType("Results", cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if typ != excelize.CellTypeSharedString && typ != excelize.CellTypeInlineString {
+			t.Errorf("文件大小 %s 的类型为 %v，应为字符串", cell, typ)
+		}
+	}
+	for _, cell := range []string{"C2", "C3"} {
+		typ, err := f.GetCellType("Results", cell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 没有 t 属性的单元格即为数字
+		if typ != excelize.CellTypeNumber && typ != excelize.CellTypeUnset {
+			t.Errorf("字节数 %s 的类型为 %v，应为数字", cell, typ)
+		}
+	}
+	if raw, _ := f.GetCellValue("Results", "C2", excelize.Options{RawCellValue: true}); raw != "3000000" {
+		t.Errorf("C2 原始值 = %q，应为 3000000", raw)
+	}
+}
+
+func TestExcelBytesNumberFormat(t *testing.T) {
+	f := writeTestWorkbook(t)
+	id, err := f.GetCellStyle("Results", "C2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	style, err := f.GetStyle(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// #,##0 是内置格式 3，excelize 可能按内置编号保存
+	if style.NumFmt != 3 && (style.CustomNumFmt == nil || *style.CustomNumFmt != "#,##0") {
+		t.Errorf("字节数列的数字格式为 NumFmt = %d, CustomNumFmt = %v，应为 #,##0", style.NumFmt, style.CustomNumFmt)
+	}
+	if shown, _ := f.GetCellValue("Results", "C2"); shown != "3,000,000" {
+		t.Errorf("C2 显示为 %q，应为 3,000,000", shown)
+	}
+	if header, _ := f.GetCellStyle("Results", "C1"); header == id {
+		t.Error("表头不应使用数字格式")
+	}
+}
+
+func TestCancelWhileAllSlotsHeld(t *testing.T) {
+	srv := newSizeServer(t)
+	baseline := runtime.NumGoroutine()
+
+	// 两个槽都被一直不响应的请求占住，其余 URL 在等待槽位
+	urls := []string{srv.URL + "/hang", srv.URL + "/hang", srv.URL + "/size/1", srv.URL + "/size/2"}
+	a := NewApp()
+	done := make(chan error, 1)
+	var results []Result
+	go func() {
+		var err error
+		results, err = a.CheckFileSizeConcurrent(urls, 2, "")
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	a.CancelCheck()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrCancelled) {
+			t.Fatalf("err = %v，应为 ErrCancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("所有槽都被占用时取消没有及时生效")
+	}
+	if len(results) != len(urls) {
+		t.Fatalf("得到 %d 条结果，应为 %d 条", len(results), len(urls))
+	}
+	for _, r := range results {
+		if !r.Failed() {
+			t.Errorf("%s 在取消前不应完成", r.URL)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		t.Fatalf("取消后 goroutine 数为 %d，检查前为 %d", n, baseline)
+	}
+}
+
+// resultURLs 返回结果的 URL 列表
+func resultURLs(results []Result) []string {
+	urls := make([]string, len(results))
+	for i, r := range results {
+		urls[i] = r.URL
+	}
+	return urls
+}
+
+func TestSortResultsEqualSizes(t *testing.T) {
+	failed := failedResult("http://a.example.com/failed", errUnknownSize)
+	input := []Result{
+		{URL: "http://example.com/d", Bytes: 100},
+		failed,
+		{URL: "http://example.com/b", Bytes: 100},
+		{URL: "http://example.com/big", Bytes: 500},
+		{URL: "http://example.com/a", Bytes: 100},
+		{URL: "http://example.com/c", Bytes: 100},
+	}
+	want := []string{
+		"http://example.com/big",
+		"http://example.com/a",
+		"http://example.com/b",
+		"http://example.com/c",
+		"http://example.com/d",
+		"http://a.example.com/failed",
+	}
+	// 不论输入顺序如何，大小相同的结果都按 URL 排列
+	for i := 0; i < len(input); i++ {
+		results := append(append([]Result{}, input[i:]...), input[:i]...)
+		sortResults(results, SortBySize, SortDefault)
+		if got := resultURLs(results); strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Fatalf("从第 %d 条开始的输入排序为 %q，应为 %q", i, got, want)
+		}
+	}
+}
+
+func TestSortResultsByColumn(t *testing.T) {
+	notFound := failedResult("http://example.com/0-missing", &statusError{code: 404})
+	input := []Result{
+		{URL: "http://example.com/c", Bytes: 10, StatusCode: 206},
+		notFound,
+		{URL: "http://example.com/a", Bytes: 30, StatusCode: 200},
+		{URL: "http://example.com/b", Bytes: 20, StatusCode: 200},
+	}
+	tests := []struct {
+		by    SortColumn
+		order SortOrder
+		want  []string
+	}{
+		{SortByURL, SortDefault, []string{"http://example.com/a", "http://example.com/b", "http://example.com/c", notFound.URL}},
+		{SortByURL, SortDesc, []string{"http://example.com/c", "http://example.com/b", "http://example.com/a", notFound.URL}},
+		// 状态码相同时按 URL 排列，失败的 404 仍在最后
+		{SortByStatus, SortDefault, []string{"http://example.com/a", "http://example.com/b", "http://example.com/c", notFound.URL}},
+		{SortByStatus, SortDesc, []string{"http://example.com/c", "http://example.com/a", "http://example.com/b", notFound.URL}},
+	}
+	for _, tt := range tests {
+		results := append([]Result{}, input...)
+		sortResults(results, tt.by, tt.order)
+		if got := resultURLs(results); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("按 %q %q 排序为 %q，应为 %q", tt.by, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestRecheckFailedKeepsCheckpoint(t *testing.T) {
+	srv := newSizeServer(t)
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+
+	a := NewApp()
+	a.SetOptions(Options{CheckpointFile: checkpoint})
+	results, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/size/1", srv.URL + "/size/2", srv.URL + "/missing"}, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.RecheckFailed(results, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Fatalf("补查修改了检查点:\n补查前:\n%s\n补查后:\n%s", before, after)
+	}
+	done, err := readCheckpoint(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != 3 {
+		t.Fatalf("检查点中有 %d 条结果，应为 3 条", len(done))
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// autoSteps auto 策略的回退链
+var autoSteps = []SizeStrategy{StrategyHeadStep, StrategyRangeStep, StrategyGet}
+
+// autoSize 组合策略：先发 HEAD；失败或没有 Content-Length 时发 Range: bytes=0-0 的 GET，
+// 从 Content-Range 读取总大小；仍失败时下载完整响应体计数，完整下载受 MaxTotalBytes 预算限制
+func (c *checker) autoSize(ctx context.Context, t Target) (Result, error) {
+	return c.chainSize(ctx, t, autoSteps)
+}
+
+// chainSize 依次尝试 steps 中的方式，返回第一个得到大小的结果，ctx 结束时立即停止。
+// 全部失败时返回最后一步的错误，实际得到大小的方式记录在 SizeMethod 中。
+// 字节预算耗尽时跳过完整 GET，并在上一步的结果中备注“预算耗尽”
+func (c *checker) chainSize(ctx context.Context, t Target, steps []SizeStrategy) (Result, error) {
+	var r Result
+	var err error
+	var attempts []Attempt
+	for _, step := range steps {
+		if step == StrategyGet && c.budgetExhausted() {
+			attempts = append(attempts, Attempt{Strategy: step, Err: errBudgetExhausted.Error()})
+			r.Note = errBudgetExhausted.Error()
+			if err == nil {
+				err = errBudgetExhausted
+			}
+			continue
+		}
+
+		next, nextErr := c.stepSize(ctx, t, step)
+		attempts = append(attempts, newAttempt(step, next, nextErr))
+		if step == StrategyGet && errors.Is(nextErr, errBudgetExhausted) {
+			r.Note = errBudgetExhausted.Error()
+			if err == nil {
+				err = errBudgetExhausted
+			}
+			continue
+		}
+		r, err = next, nextErr
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	r.Attempts = attempts
+	return r, err
+}
+
+// newAttempt 根据一步的结果构造尝试记录
+func newAttempt(step SizeStrategy, r Result, err error) Attempt {
+	a := Attempt{Strategy: step, StatusCode: r.StatusCode}
+	if err != nil {
+		a.Err = err.Error()
+		var se *statusError
+		if errors.As(err, &se) {
+			a.StatusCode = se.code
+		}
+	}
+	return a
+}
+
+// stepSize 按回退链中的一种方式获取大小
+func (c *checker) stepSize(ctx context.Context, t Target, step SizeStrategy) (Result, error) {
+	switch step {
+	case StrategyOptions:
+		return c.requestSize(ctx, http.MethodOptions, t, nil, c.headerSize)
+	case StrategyRangeStep:
+		r, err := c.requestSize(ctx, http.MethodGet, t, http.Header{"Range": {"bytes=0-0"}}, contentRange)
+		if err == nil && r.StatusCode == http.StatusPartialContent {
+			r.SizeMethod = "Range"
+		}
+		var se *statusError
+		if errors.As(err, &se) && se.code == http.StatusRequestedRangeNotSatisfiable {
+			return c.afterRangeNotSatisfiable(ctx, t, r, err)
+		}
+		return r, err
+	case StrategyGet:
+		return c.requestSize(ctx, http.MethodGet, t, c.bodyHeader(), c.countBody)
+	default:
+		return c.requestSize(ctx, http.MethodHead, t, nil, contentLength)
+	}
+}
+
+// afterRangeNotSatisfiable 空文件或不支持 Range 的资源可能对 bytes=0-0 返回 416，
+// 这时改用 HEAD，再不行用不读响应体的 GET 读取 Content-Length，并在备注中说明。都失败时返回 416 的结果
+func (c *checker) afterRangeNotSatisfiable(ctx context.Context, t Target, rangeResult Result, rangeErr error) (Result, error) {
+	r, err := c.requestSize(ctx, http.MethodHead, t, nil, contentLength)
+	if err != nil && ctx.Err() == nil {
+		r, err = c.requestSize(ctx, http.MethodGet, t, nil, contentLength)
+	}
+	if err != nil {
+		return rangeResult, rangeErr
+	}
+	r.Note = "Range 返回 416"
+	return r, nil
+}
+
+// contentRange 从 206 响应的 Content-Range（如 bytes 0-0/1234）读取总大小。
+// 服务器忽略 Range 返回 200 时按 Content-Length 处理
+func contentRange(resp *http.Response, r *Result) (int64, error) {
+	if resp.StatusCode != http.StatusPartialContent {
+		return contentLength(resp, r)
+	}
+
+	value := resp.Header.Get("Content-Range")
+	_, total, ok := strings.Cut(value, "/")
+	if !ok || !strings.HasPrefix(value, "bytes ") {
+		return 0, fmt.Errorf("无效的 Content-Range: %q", value)
+	}
+	if total == "*" {
+		return 0, errUnknownSize
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("无效的 Content-Range: %q", value)
+	}
+	return size, nil
+}
+
+// rangeWorks 验证服务器是否真正支持 Range：发送 Range: bytes=0-0 的 GET，
+// 只有返回 206、Content-Range 为 bytes 0-0/总大小且响应体恰好 1 字节时才算支持。
+// 返回 200 和完整响应体（忽略 Range）或任何错误都算不支持
+func (c *checker) rangeWorks(ctx context.Context, t Target) bool {
+	_, err := c.requestSize(ctx, http.MethodGet, t, http.Header{"Range": {"bytes=0-0"}}, checkRange)
+	return err == nil
+}
+
+// checkRange 校验 bytes=0-0 的 Range 响应，通过时返回总大小
+func checkRange(resp *http.Response, r *Result) (int64, error) {
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, errors.New("服务器忽略了 Range 请求")
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Range"), "bytes 0-0/") {
+		return 0, fmt.Errorf("Content-Range 不匹配: %q", resp.Header.Get("Content-Range"))
+	}
+	size, err := contentRange(resp, r)
+	if err != nil {
+		return 0, err
+	}
+
+	// 多读一个字节，以发现返回内容多于请求范围的情况
+	n, err := io.Copy(io.Discard, io.LimitReader(resp.Body, 2))
+	if err != nil {
+		return 0, err
+	}
+	if n != 1 {
+		return 0, fmt.Errorf("Range 响应体为 %d 字节，应为 1 字节", n)
+	}
+	return size, nil
+}
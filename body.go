@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// errBudgetExhausted 读取响应体的总字节数预算已用完
+var errBudgetExhausted = errors.New("预算耗尽")
+
+// errBodyLimit 单个响应体超过 MaxBodyBytes，已停止读取
+var errBodyLimit = errors.New("超过限制")
+
+// getBodySize 发送 GET 请求并统计响应体的实际字节数。
+// 字节预算已耗尽时不再下载，改用 HEAD 的 Content-Length
+func (c *checker) getBodySize(ctx context.Context, t Target) (Result, error) {
+	if c.budgetExhausted() {
+		return c.headAfterBudget(ctx, t)
+	}
+
+	r, err := c.requestSize(ctx, http.MethodGet, t, c.bodyHeader(), c.countBody)
+	if errors.Is(err, errBudgetExhausted) {
+		return c.headAfterBudget(ctx, t)
+	}
+	return r, err
+}
+
+// headAfterBudget 预算耗尽后用 HEAD 获取大小，并在备注中标记
+func (c *checker) headAfterBudget(ctx context.Context, t Target) (Result, error) {
+	r, err := c.requestSize(ctx, http.MethodHead, t, nil, contentLength)
+	r.Note = errBudgetExhausted.Error()
+	return r, err
+}
+
+// bodyHeader 下载响应体时附加的请求头。统计压缩比时显式声明支持的压缩格式，
+// 这样 Go 不会自动解压，可以分别统计传输和解压后的字节数
+func (c *checker) bodyHeader() http.Header {
+	if !c.opts.MeasureCompression {
+		return nil
+	}
+	return http.Header{"Accept-Encoding": {"gzip, deflate, br"}}
+}
+
+// countBody 读取并丢弃响应体，返回实际读取的字节数。响应带 Content-Length 且与实际收到的
+// 字节数不一致时（如连接中途断开）标记为下载不完整，大小仍为实际收到的字节数
+func (c *checker) countBody(resp *http.Response, r *Result) (int64, error) {
+	restore, err := c.weighBody(resp.Request.Context(), resp.ContentLength)
+	if err != nil {
+		return 0, err
+	}
+	defer restore()
+
+	body := &countingReader{r: c.budgetReader(c.throttle(resp.Request.Context(), resp.Body))}
+	if c.opts.MeasureCompression {
+		r.ContentEncoding = strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+		if decoder, ok := decoders[r.ContentEncoding]; ok {
+			return c.countDecodedBody(resp, body, decoder, r)
+		}
+	}
+
+	n, err := c.drain(body)
+	if errors.Is(err, errBodyLimit) {
+		r.Bytes = n
+		return n, err
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) && resp.ContentLength > 0 {
+		err = nil
+	}
+	if err != nil {
+		return n, err
+	}
+	r.Truncated = resp.ContentLength >= 0 && n != resp.ContentLength
+	if c.opts.MeasureCompression {
+		r.TransferBytes = n
+	}
+	return n, nil
+}
+
+// decoders 统计压缩比时支持解压的 Content-Encoding，其他编码只记录传输字节数
+var decoders = map[string]func(io.Reader) (io.Reader, error){
+	"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	"deflate": func(r io.Reader) (io.Reader, error) {
+		// 按规范 deflate 是 zlib 格式，但有些服务器直接发送裸 deflate 数据，按 zlib 头区分
+		br := bufio.NewReader(r)
+		if h, err := br.Peek(2); err == nil && h[0]&0x0f == 8 && (uint(h[0])<<8|uint(h[1]))%31 == 0 {
+			return zlib.NewReader(br)
+		}
+		return flate.NewReader(br), nil
+	},
+	"br": func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+}
+
+// countDecodedBody 边解压边计数压缩的响应体，返回解压后的字节数，
+// 同时记录传输字节数和压缩比（解压后 / 传输）。MaxBodyBytes 限制的是解压后的字节数
+func (c *checker) countDecodedBody(resp *http.Response, body *countingReader, decoder func(io.Reader) (io.Reader, error), r *Result) (int64, error) {
+	dr, err := decoder(body)
+	if err != nil {
+		return 0, fmt.Errorf("解压响应体失败: %w", err)
+	}
+	n, err := c.drain(dr)
+	if errors.Is(err, errBodyLimit) {
+		r.Bytes = n
+		return n, err
+	}
+	if err != nil {
+		return n, fmt.Errorf("解压响应体失败: %w", err)
+	}
+
+	r.TransferBytes = body.n
+	r.DecompressedBytes = n
+	if body.n > 0 {
+		r.CompressionRatio = float64(n) / float64(body.n)
+	}
+	r.Truncated = resp.ContentLength >= 0 && body.n != resp.ContentLength
+	return n, nil
+}
+
+// drain 读取并丢弃 body，返回读取的字节数。设置了 MaxBodyBytes 时最多读取这么多字节，
+// 还没读完时返回 errBodyLimit，防止无限长的响应体让检查一直挂起
+func (c *checker) drain(body io.Reader) (int64, error) {
+	limit := c.opts.MaxBodyBytes
+	if limit <= 0 {
+		return io.Copy(io.Discard, body)
+	}
+	n, err := io.Copy(io.Discard, io.LimitReader(body, limit+1))
+	if err == nil && n > limit {
+		return limit, errBodyLimit
+	}
+	return n, err
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// budgetExhausted 判断字节预算是否已用完
+func (c *checker) budgetExhausted() bool {
+	return c.opts.MaxTotalBytes > 0 && c.bodyBytes.Load() >= c.opts.MaxTotalBytes
+}
+
+// budgetReader 包装响应体，读取时计入整次检查的字节预算
+func (c *checker) budgetReader(r io.Reader) io.Reader {
+	return &budgetedReader{r: r, c: c}
+}
+
+// budgetedReader 计入字节预算的 Reader，预算用完后返回 errBudgetExhausted
+type budgetedReader struct {
+	r io.Reader
+	c *checker
+}
+
+func (b *budgetedReader) Read(p []byte) (int, error) {
+	if limit := b.c.opts.MaxTotalBytes; limit > 0 {
+		remaining := limit - b.c.bodyBytes.Load()
+		if remaining <= 0 {
+			return 0, errBudgetExhausted
+		}
+		if int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+
+	n, err := b.r.Read(p)
+	b.c.bodyBytes.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// errHostTripped 主机连续失败次数达到 BreakerThreshold，已熔断，没有发起请求
+var errHostTripped = errors.New("主机熔断")
+
+// hostBreaker 按主机熔断：同一主机连续失败达到阈值后，其余 URL 直接记为“主机熔断”。
+// 设置了冷却时间时，熔断超过冷却时间后放行一个请求试探，成功则恢复，失败则重新计时
+type hostBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*breakerState
+}
+
+// breakerState 单个主机的熔断状态
+type breakerState struct {
+	failures int       // 连续失败次数
+	open     bool      // 是否处于熔断
+	openedAt time.Time // 最近一次熔断或试探失败的时间
+	probing  bool      // 是否有试探请求正在进行
+	tripped  bool      // 本次检查中是否熔断过
+}
+
+// newHostBreaker 设置了 BreakerThreshold 时创建熔断器，否则返回 nil
+func newHostBreaker(opts Options) *hostBreaker {
+	if opts.BreakerThreshold <= 0 {
+		return nil
+	}
+	return &hostBreaker{
+		threshold: opts.BreakerThreshold,
+		cooldown:  time.Duration(opts.BreakerCooldown),
+		hosts:     make(map[string]*breakerState),
+	}
+}
+
+// allow 判断是否可以向 URL 的主机发起请求。没有主机的 URL（如 data:、file:）总是允许
+func (b *hostBreaker) allow(rawURL string) bool {
+	host := breakerHost(rawURL)
+	if b == nil || host == "" {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.hosts[host]
+	if s == nil || !s.open {
+		return true
+	}
+	if b.cooldown > 0 && !s.probing && time.Since(s.openedAt) >= b.cooldown {
+		s.probing = true
+		return true
+	}
+	return false
+}
+
+// record 记录一次检查的结果。只有网络错误、超时、5xx 和 429 算作主机故障，
+// 404 等说明主机正常响应的失败会清零连续失败次数。被取消的请求不计入结果，
+// 若它是试探请求则保持熔断，下一个 URL 可以重新试探
+func (b *hostBreaker) record(rawURL string, err error) {
+	host := breakerHost(rawURL)
+	if b == nil || host == "" {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := b.hosts[host]
+	if errors.Is(err, context.Canceled) {
+		if s != nil {
+			s.probing = false
+		}
+		return
+	}
+	if s == nil {
+		s = &breakerState{}
+		b.hosts[host] = s
+	}
+	if !hostFault(err) {
+		s.failures, s.open, s.probing = 0, false, false
+		return
+	}
+
+	s.failures++
+	if s.probing || s.failures >= b.threshold {
+		s.open, s.probing, s.tripped = true, false, true
+		s.openedAt = time.Now()
+	}
+}
+
+// trippedHosts 返回本次检查中熔断过的主机，按名称排序
+func (b *hostBreaker) trippedHosts() []string {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var hosts []string
+	for host, s := range b.hosts {
+		if s.tripped {
+			hosts = append(hosts, host)
+		}
+	}
+	slices.Sort(hosts)
+	return hosts
+}
+
+// hostFault 判断错误是否说明主机出了问题
+func hostFault(err error) bool {
+	if err == nil || errors.Is(err, errUnknownSize) || errors.Is(err, errBodyLimit) ||
+		errors.Is(err, errHostFiltered) || errors.Is(err, errBudgetExhausted) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == 429
+	}
+	return true
+}
+
+// breakerHost 返回熔断按其计数的主机名，无法解析或没有主机时返回空串
+func breakerHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHostBreakerTrips(t *testing.T) {
+	b := newHostBreaker(Options{BreakerThreshold: 2})
+	const u = "http://example.com/a"
+
+	b.record(u, &statusError{code: 503})
+	if !b.allow(u) {
+		t.Fatal("一次故障后就熔断了")
+	}
+	b.record(u, &statusError{code: 404}) // 主机正常响应，清零
+	b.record(u, &statusError{code: 503})
+	if !b.allow(u) {
+		t.Fatal("404 没有清零连续失败次数")
+	}
+	b.record(u, errors.New("connection refused"))
+	if b.allow(u) {
+		t.Fatal("连续两次故障后仍允许请求")
+	}
+	if !b.allow("http://other.example.com/a") {
+		t.Fatal("熔断影响了其他主机")
+	}
+	if got := fmt.Sprint(b.trippedHosts()); got != "[example.com]" {
+		t.Fatalf("trippedHosts = %s", got)
+	}
+}
+
+func TestHostBreakerCancelledProbe(t *testing.T) {
+	b := newHostBreaker(Options{BreakerThreshold: 1, BreakerCooldown: Duration(10 * time.Millisecond)})
+	const u = "http://example.com/a"
+
+	b.record(u, &statusError{code: 500})
+	if b.allow(u) {
+		t.Fatal("熔断后立即允许了请求")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !b.allow(u) {
+		t.Fatal("冷却后没有放行试探请求")
+	}
+	if b.allow(u) {
+		t.Fatal("试探进行中又放行了请求")
+	}
+
+	// 试探被取消：保持熔断，但可以再次试探
+	b.record(u, fmt.Errorf("请求失败: %w", context.Canceled))
+	if !b.allow(u) {
+		t.Fatal("试探被取消后无法再次试探")
+	}
+	b.record(u, nil)
+	if !b.allow(u) || !b.allow(u) {
+		t.Fatal("试探成功后没有恢复")
+	}
+}
@@ -0,0 +1,558 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// errTooManyOpenFiles 文件描述符耗尽，通常是并发数过高或 ulimit -n 过低
+var errTooManyOpenFiles = errors.New("打开的文件过多，请降低并发数、设置 maxOpenFiles 或调高 ulimit -n")
+
+// errUnknownSize 服务器未返回文件大小
+var errUnknownSize = errors.New("无法确定文件大小")
+
+// errWatchdog 单个 URL 的检查超过了 WatchdogTimeout
+var errWatchdog = errors.New("超时(看门狗)")
+
+// errRequestLimit 已达到 MaxRequests 请求数上限，URL 未检查
+var errRequestLimit = errors.New("已达到请求数上限")
+
+// statusError 非 200 的 HTTP 状态码
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("HTTP 状态码: %d", e.code)
+}
+
+// checker 持有一次检查共用的 HTTP 客户端和选项
+type checker struct {
+	client *http.Client
+	opts   Options
+
+	hostMu   sync.Mutex
+	hostNext map[string]time.Time // 每个主机下一次允许发起请求的时间
+
+	bodyBytes atomic.Int64      // 整次检查读取的响应体字节数，用于 MaxTotalBytes 预算
+	bandwidth *bandwidthLimiter // 设置 MaxBandwidth 时所有 worker 共用的限速器，否则为 nil
+	breaker   *hostBreaker      // 设置 BreakerThreshold 时按主机熔断，否则为 nil
+
+	requests atomic.Int64 // 整次检查发出的 HTTP 请求数，用于 MaxRequests 上限和统计
+	uaNext   atomic.Int64 // 下一个要使用的 UserAgents 下标
+	retries  atomic.Int64 // 整次检查已用的重试次数，用于 RetryBudget 和统计
+
+	metrics *metrics // 为 nil 时不记录指标
+}
+
+// newChecker 根据选项创建 checker
+func newChecker(opts Options) (*checker, error) {
+	client, err := newHTTPClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	c := &checker{client: client, opts: opts, hostNext: make(map[string]time.Time), breaker: newHostBreaker(opts)}
+	if opts.MaxBandwidth > 0 {
+		c.bandwidth = &bandwidthLimiter{rate: opts.MaxBandwidth}
+	}
+	return c, nil
+}
+
+// SizeChecker 检查单个目标的文件大小，失败时返回错误，结果中同时记录失败原因。
+// 并发检查通过它完成每个目标的检查，库调用方可以用 Options.Checker 换成自己的实现
+// （如测试中不发网络请求的假实现）。会被多个 goroutine 同时调用，必须是并发安全的
+type SizeChecker interface {
+	Check(ctx context.Context, t Target) (Result, error)
+}
+
+// Check 实现 SizeChecker，检查单个目标（含重试和看门狗）
+func (c *checker) Check(ctx context.Context, t Target) (Result, error) {
+	r := c.checkWithWatchdog(ctx, t)
+	if r.Failed() {
+		return r, errors.New(r.Err)
+	}
+	return r, nil
+}
+
+// checkTarget 用 sc 检查一个目标，总是返回一条 URL 为原文的结果，sc 返回的错误记入结果
+func checkTarget(ctx context.Context, sc SizeChecker, t Target) Result {
+	r, err := sc.Check(ctx, t)
+	r.URL = t.URL
+	if err != nil && !r.Failed() {
+		r.fail(err)
+	}
+	return r
+}
+
+// checkURL 校验并检查单个目标，总是返回一条结果，失败原因记录在 Err 中。
+// 设置了 Repeat 时重复检查以判断大小是否稳定，开启 CompareSchemes 时还会检查另一协议（http/https 互换）的同一 URL
+func (c *checker) checkURL(ctx context.Context, t Target) Result {
+	r := c.checkOne(ctx, t)
+	if c.opts.Repeat > 1 && !r.Failed() {
+		c.repeatProbes(ctx, t, &r)
+	}
+
+	if c.opts.CompareSchemes {
+		if altURL, ok := swapScheme(t.URL); ok {
+			alt := c.checkOne(ctx, Target{URL: altURL, Timeout: t.Timeout, Headers: t.Headers})
+			r.Alt = &alt
+			r.SchemeMatch = !r.Failed() && !alt.Failed() && r.Bytes == alt.Bytes
+		}
+	}
+
+	return r
+}
+
+// checkWithWatchdog 同 checkURL，设置了 WatchdogTimeout 时为整个检查（含重试）加一道硬性期限：
+// 到期后取消该 URL 的请求，等它退出后返回“超时(看门狗)”的失败结果，
+// 用于防范服务器持续缓慢发送数据等单次请求超时无法覆盖的情况。
+// 必须等检查的 goroutine 返回：它还持有 ctx 中的并发槽位（见 withSlot），提前返回会让两个 goroutine 同时使用同一个 slot
+func (c *checker) checkWithWatchdog(ctx context.Context, t Target) Result {
+	limit := time.Duration(c.opts.WatchdogTimeout)
+	if limit <= 0 {
+		return c.checkURL(ctx, t)
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan Result, 1)
+	go func() { done <- c.checkURL(ctx, t) }()
+
+	timer := time.NewTimer(limit)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r
+	case <-timer.C:
+		cancel(errWatchdog)
+		<-done
+		r := failedResult(t.URL, errWatchdog)
+		r.CheckedAt = time.Now()
+		r.Elapsed = Duration(r.CheckedAt.Sub(start))
+		return r
+	}
+}
+
+// checkOne 校验并检查单个目标
+func (c *checker) checkOne(ctx context.Context, t Target) Result {
+	start := time.Now()
+	var r Result
+	u := t.URL
+	expanded, err := expandURL(u, c.opts)
+	normalized := ""
+	if err == nil {
+		normalized, err = normalizeURL(expanded)
+	}
+	if err == nil && !c.hostAllowed(normalized) {
+		err = errHostFiltered
+	}
+	if err == nil && !c.breaker.allow(normalized) {
+		err = errHostTripped
+	}
+	if err == nil {
+		t.URL = normalized
+		r, err = c.getFileSize(ctx, t)
+		c.breaker.record(normalized, err)
+	}
+	if err == nil && c.opts.RangeCheck && strings.HasPrefix(t.URL, "http") {
+		r.RangeActuallyWorks = c.rangeWorks(ctx, t)
+	}
+	if err == nil && t.ExpectedSize > 0 {
+		r.ExpectedBytes = t.ExpectedSize
+		r.SizeDelta = r.Bytes - t.ExpectedSize
+		r.SizeMismatch = !c.withinTolerance(r.SizeDelta, t.ExpectedSize)
+	}
+
+	r.URL = u
+	// 跟随重定向后最终仍是明文 HTTP，请求未完成时按输入的 URL 判断
+	final := r.FinalURL
+	if final == "" {
+		final = normalized
+	}
+	r.Insecure = strings.HasPrefix(final, "http:")
+	if err != nil {
+		if errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE) {
+			err = fmt.Errorf("%w: %w", errTooManyOpenFiles, err)
+		}
+		partial := r.Bytes
+		r.fail(err)
+		switch {
+		case errors.Is(err, errHostFiltered):
+			r.Size = errHostFiltered.Error()
+		case errors.Is(err, errHostTripped):
+			r.Size = errHostTripped.Error()
+		case errors.Is(err, errBodyLimit):
+			// 保留已读取的字节数，便于判断实际大小至少有多大
+			r.Size = errBodyLimit.Error()
+			r.Bytes = partial
+			r.HitSizeCap = true
+			r.SizeMethod = http.MethodGet // 只有 GET 会读取响应体
+		}
+	}
+	r.CheckedAt = time.Now()
+	r.Elapsed = Duration(r.CheckedAt.Sub(start))
+	return r
+}
+
+// getFileSize 获取指定 URL 文件的大小，支持 context 取消，失败时按配置重试。
+// 返回的结果不含 URL，由调用方填写
+func (c *checker) getFileSize(ctx context.Context, t Target) (Result, error) {
+	for attempt := 0; ; attempt++ {
+		r, err := c.fetchSize(ctx, t)
+		if err == nil || attempt >= c.opts.Retries || !c.retryable(ctx, err) || !c.methodRetryable(t) || !c.takeRetry() {
+			c.metrics.observeResult(r, err)
+			return r, err
+		}
+		c.metrics.observeRetry()
+
+		// 指数退避：500ms、1s、2s……
+		select {
+		case <-ctx.Done():
+			c.metrics.observeResult(r, ctx.Err())
+			return r, ctx.Err()
+		case <-time.After(500 * time.Millisecond << attempt):
+		}
+	}
+}
+
+// fetchSize 按 URL 协议和配置的取大小策略请求一次
+func (c *checker) fetchSize(ctx context.Context, t Target) (Result, error) {
+	// t.URL 已经过 normalizeURL，协议为小写
+	scheme, _, _ := strings.Cut(t.URL, ":")
+	switch scheme {
+	case "ftp":
+		return c.ftpSize(ctx, t)
+	case "file":
+		return fileSize(t)
+	case "data":
+		return dataSize(t)
+	}
+
+	if len(c.opts.Strategies) > 0 {
+		// 整个回退链共用一次请求超时
+		ctx, cancel := context.WithTimeout(ctx, c.timeout(t))
+		defer cancel()
+		return c.chainSize(ctx, t, c.opts.Strategies)
+	}
+
+	switch c.opts.SizeStrategy {
+	case StrategyOptions:
+		return c.requestSize(ctx, c.method(http.MethodOptions), t, nil, c.headerSize)
+	case StrategyGet:
+		return c.getBodySize(ctx, t)
+	case StrategyAuto:
+		return c.autoSize(ctx, t)
+	default:
+		r, err := c.requestSize(ctx, c.method(http.MethodHead), t, nil, contentLength)
+		if c.opts.GetOnEmptyHead && (err == nil && r.Bytes == 0 || errors.Is(err, errUnknownSize)) {
+			return c.getAfterEmptyHead(ctx, t, r, err)
+		}
+		return r, err
+	}
+}
+
+// getAfterEmptyHead HEAD 得到的大小为 0 或未知时改发 GET，只读 Content-Length 不下载响应体。
+// GET 失败时返回 HEAD 的结果
+func (c *checker) getAfterEmptyHead(ctx context.Context, t Target, head Result, headErr error) (Result, error) {
+	r, err := c.requestSize(ctx, http.MethodGet, t, nil, contentLength)
+	if err != nil {
+		return head, headErr
+	}
+	return r, nil
+}
+
+// timeout 目标的请求超时，未单独设置时使用全局超时
+func (c *checker) timeout(t Target) time.Duration {
+	if t.Timeout > 0 {
+		return time.Duration(t.Timeout)
+	}
+	return time.Duration(c.opts.Timeout)
+}
+
+// method 默认策略和 options 策略使用的请求方法，未设置 Method 时为 fallback
+func (c *checker) method(fallback string) string {
+	if c.opts.Method == "" {
+		return fallback
+	}
+	return strings.ToUpper(c.opts.Method)
+}
+
+// methodRetryable 判断目标的请求方法是否允许重试：POST、PATCH 只在开启 RetryNonIdempotent 时重试，
+// get、auto 策略和回退链不使用 Method，非 HTTP 协议不涉及请求方法，总是允许
+func (c *checker) methodRetryable(t Target) bool {
+	if c.opts.RetryNonIdempotent || len(c.opts.Strategies) > 0 || !strings.HasPrefix(t.URL, "http") {
+		return true
+	}
+	switch c.opts.SizeStrategy {
+	case StrategyGet, StrategyAuto:
+		return true
+	}
+	switch c.method("") {
+	case http.MethodPost, http.MethodPatch:
+		return false
+	}
+	return true
+}
+
+// requestSize 发送一次请求，并用 readSize 从响应中读取文件大小，header 为额外的请求头（可为 nil）。
+// 每次请求单独计算超时，目标自带的超时优先于全局超时
+func (c *checker) requestSize(ctx context.Context, method string, t Target, header http.Header, readSize sizeReader) (Result, error) {
+	var r Result
+
+	// 随机等待不计入请求超时
+	if err := c.waitJitter(ctx); err != nil {
+		return r, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout(t))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, t.URL, nil)
+	if err != nil {
+		return r, err
+	}
+	for key, value := range c.opts.Headers {
+		req.Header.Set(key, value)
+	}
+	if c.opts.Accept != "" {
+		req.Header.Set("Accept", c.opts.Accept)
+	}
+	if ua := c.nextUserAgent(); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	if c.opts.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.BearerToken)
+	}
+	// 目标单独的请求头优先于全局设置
+	for key, value := range t.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.opts.HostHeader != "" {
+		req.Host = c.opts.HostHeader
+	}
+
+	if err := c.waitHost(ctx, req.URL.Host); err != nil {
+		return r, err
+	}
+
+	var redirects int
+	var remoteAddr net.Addr
+	trace := &httptrace.ClientTrace{
+		// 复用的连接同样会回调，重定向时以最后一跳为准
+		GotConn: func(info httptrace.GotConnInfo) { remoteAddr = info.Conn.RemoteAddr() },
+	}
+	req = req.WithContext(httptrace.WithClientTrace(context.WithValue(req.Context(), redirectCountKey{}, &redirects), trace))
+
+	c.requests.Add(1)
+	start := time.Now()
+	defer func() { c.metrics.observeRequest(time.Since(start)) }()
+	if c.opts.OnRequestStart != nil {
+		c.opts.OnRequestStart(req.URL.String())
+	}
+	resp, err := c.client.Do(req)
+	r.RedirectCount = redirects
+	r.RemoteAddr = remoteIP(remoteAddr)
+	if err != nil {
+		return r, err
+	}
+	defer resp.Body.Close()
+
+	r.StatusCode = resp.StatusCode
+	r.Proto = resp.Proto
+	r.ContentType = resp.Header.Get("Content-Type")
+	r.ETag = resp.Header.Get("ETag")
+	r.AcceptRanges = strings.EqualFold(resp.Header.Get("Accept-Ranges"), "bytes")
+	r.FinalURL = resp.Request.URL.String()
+	r.CrossHostRedirect = !strings.EqualFold(req.URL.Hostname(), resp.Request.URL.Hostname())
+	if c.opts.CaptureHeaders {
+		r.Headers = resp.Header.Clone()
+	}
+
+	if !c.acceptStatus(resp.StatusCode) {
+		return r, &statusError{code: resp.StatusCode}
+	}
+
+	size, err := readSize(resp, &r)
+	if err != nil {
+		return r, err
+	}
+
+	r.SizeMethod = method
+	r.Bytes = size
+	r.Size = formatFileSize(size)
+	r.SuspectSoftFail = c.opts.SoftFailCheck && mediaType(r.ContentType) == "text/html" && size < c.opts.SoftFailThreshold
+	return r, nil
+}
+
+// remoteIP 返回连接对端地址中的 IP，addr 为 nil 时返回空串
+func remoteIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		return host
+	}
+	return addr.String()
+}
+
+// sizeReader 从响应中读取文件大小，必要时可向结果补充信息
+type sizeReader func(resp *http.Response, r *Result) (int64, error)
+
+// contentLength 从 Content-Length 读取文件大小。Content-Length: 0 是有效的空文件，
+// 只有没有该响应头（如分块传输，ContentLength 为 -1）时才是无法确定大小
+func contentLength(resp *http.Response, _ *Result) (int64, error) {
+	if resp.ContentLength < 0 {
+		return 0, errUnknownSize
+	}
+	return resp.ContentLength, nil
+}
+
+// headerSize 从 SizeHeader 指定的响应头读取文件大小，用于 OPTIONS 策略
+func (c *checker) headerSize(resp *http.Response, _ *Result) (int64, error) {
+	value := strings.TrimSpace(resp.Header.Get(c.opts.SizeHeader))
+	if value == "" {
+		return 0, errUnknownSize
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("响应头 %s 不是有效的文件大小: %q", c.opts.SizeHeader, value)
+	}
+	return size, nil
+}
+
+// withinTolerance 判断实际大小与预期大小之差 delta 是否在允许误差内，
+// 满足绝对误差 SizeTolerance 或相对误差 SizeTolerancePercent 之一即可；两者都为 0 时要求完全一致
+func (c *checker) withinTolerance(delta, expected int64) bool {
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta <= c.opts.SizeTolerance {
+		return true
+	}
+	return float64(delta) <= float64(expected)*c.opts.SizeTolerancePercent/100
+}
+
+// waitHost 保证对同一主机的相邻两次请求至少间隔 HostDelay。
+// 先在锁内预约发起时间，再在锁外等待，多个 worker 访问同一主机时依次错开
+func (c *checker) waitHost(ctx context.Context, host string) error {
+	delay := time.Duration(c.opts.HostDelay)
+	if delay <= 0 {
+		return nil
+	}
+
+	c.hostMu.Lock()
+	now := time.Now()
+	at := c.hostNext[host]
+	if at.Before(now) {
+		at = now
+	}
+	c.hostNext[host] = at.Add(delay)
+	c.hostMu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// waitJitter 在 [JitterMin, JitterMax] 内随机等待一段时间，ctx 结束时提前返回
+func (c *checker) waitJitter(ctx context.Context) error {
+	lo, hi := time.Duration(c.opts.JitterMin), time.Duration(c.opts.JitterMax)
+	if hi <= 0 {
+		return nil
+	}
+	wait := lo + time.Duration(rand.Int63n(int64(hi-lo)+1))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// hostAllowed 判断是否允许访问 URL 的主机，未设置主机过滤或 URL 没有主机（如 data:、file:）时允许
+func (c *checker) hostAllowed(rawURL string) bool {
+	if len(c.opts.AllowHosts) == 0 && len(c.opts.DenyHosts) == 0 {
+		return true
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true
+	}
+	return hostAllowed(u.Hostname(), c.opts)
+}
+
+// nextUserAgent 按轮换顺序返回下一个 User-Agent，未配置 UserAgents 时返回空，
+// 即使用 Headers 中的 User-Agent 或 Go 的默认值
+func (c *checker) nextUserAgent() string {
+	if len(c.opts.UserAgents) == 0 {
+		return ""
+	}
+	i := c.uaNext.Add(1) - 1
+	return c.opts.UserAgents[i%int64(len(c.opts.UserAgents))]
+}
+
+// acceptStatus 判断状态码是否视为成功：200、206（只有带 Range 的请求才会收到）
+// 以及 AcceptStatusCodes 中配置的状态码
+func (c *checker) acceptStatus(code int) bool {
+	return code == http.StatusOK || code == http.StatusPartialContent || slices.Contains(c.opts.AcceptStatusCodes, code)
+}
+
+// requestLimitReached 判断是否已达到 MaxRequests 请求数上限
+func (c *checker) requestLimitReached() bool {
+	return c.opts.MaxRequests > 0 && c.requests.Load() >= int64(c.opts.MaxRequests)
+}
+
+// takeRetry 从整次检查共用的重试预算中占用一次，预算用完时返回 false
+func (c *checker) takeRetry() bool {
+	n := c.retries.Add(1)
+	if c.opts.RetryBudget > 0 && n > int64(c.opts.RetryBudget) {
+		c.retries.Add(-1)
+		return false
+	}
+	return true
+}
+
+// retryable 判断错误是否值得重试：状态码在 RetryStatusCodes 中，或开启了网络错误重试
+func (c *checker) retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, errUnknownSize) || errors.Is(err, errHostFiltered) || errors.Is(err, errBodyLimit) {
+		return false
+	}
+
+	var se *statusError
+	if errors.As(err, &se) {
+		return slices.Contains(c.opts.RetryStatusCodes, se.code)
+	}
+
+	return *c.opts.RetryNetworkErrors
+}
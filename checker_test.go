@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatchdogTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // 接受请求后一直不响应
+	}))
+	defer srv.Close()
+
+	a := NewApp()
+	a.SetOptions(Options{WatchdogTimeout: Duration(100 * time.Millisecond)})
+	start := time.Now()
+	results, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/hang"}, 1, "")
+	if err != nil {
+		t.Fatalf("CheckFileSizeConcurrent: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("看门狗未生效，耗时 %v", elapsed)
+	}
+	r := results[0]
+	if r.Err != errWatchdog.Error() || r.FailKind != "timeout" {
+		t.Fatalf("Err = %q, FailKind = %q，应为 %q 和 timeout", r.Err, r.FailKind, errWatchdog)
+	}
+}
+
+func TestContentLengthZeroAndChunked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/empty":
+			w.Header().Set("Content-Length", "0")
+		case "/chunked":
+			// 先刷新响应头，不带 Content-Length，以分块编码发送
+			w.(http.Flusher).Flush()
+			w.Write([]byte("data"))
+		}
+	}))
+	defer srv.Close()
+
+	a := NewApp()
+	results, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/empty", srv.URL + "/chunked"}, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty, chunked := results[0], results[1]
+	if empty.Failed() || empty.Bytes != 0 || empty.Size != "0 B" {
+		t.Errorf("Content-Length: 0 应为 0 字节的有效结果: Size = %s, Err = %s", empty.Size, empty.Err)
+	}
+	if chunked.Err != errUnknownSize.Error() || chunked.FailKind != "unknown_size" {
+		t.Errorf("分块编码应为 %q: Size = %s, Err = %s", errUnknownSize, chunked.Size, chunked.Err)
+	}
+}
+
+func TestCheckIPv6Literal(t *testing.T) {
+	ln, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("不支持 IPv6:", err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "42")
+	}))
+	srv.Listener = ln
+	srv.Start()
+	defer srv.Close()
+
+	u := srv.URL + "/file"
+	if !strings.HasPrefix(u, "http://[::1]:") {
+		t.Fatalf("测试服务器地址 %s 不是 IPv6 字面量", u)
+	}
+	results, err := NewApp().CheckFileSizeConcurrent([]string{u}, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := results[0]; r.URL != u || r.Bytes != 42 {
+		t.Fatalf("URL = %s, Bytes = %d, Err = %s", r.URL, r.Bytes, r.Err)
+	}
+}
+
+func TestRetryStatusCodes(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts[r.URL.Path]++
+		mu.Unlock()
+		code, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/"))
+		w.WriteHeader(code)
+	}))
+	defer srv.Close()
+
+	a := NewApp()
+	a.SetOptions(Options{Retries: 1, RetryStatusCodes: []int{408}})
+	if _, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/408", srv.URL + "/503", srv.URL + "/404"}, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	// 只有配置的 408 会重试，默认会重试的 503 不再重试
+	want := map[string]int{"/408": 2, "/503": 1, "/404": 1}
+	mu.Lock()
+	defer mu.Unlock()
+	for path, n := range want {
+		if attempts[path] != n {
+			t.Errorf("%s 请求了 %d 次，应为 %d 次", path, attempts[path], n)
+		}
+	}
+}
+
+func TestRetryNetworkErrors(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := "http://" + ln.Addr().String() + "/a"
+	ln.Close() // 连接会被拒绝
+
+	retry := false
+	tests := []struct {
+		opts     Options
+		requests int64
+	}{
+		{Options{Retries: 1}, 2},
+		{Options{Retries: 1, RetryNetworkErrors: &retry}, 1},
+	}
+	for _, tt := range tests {
+		a := NewApp()
+		a.SetOptions(tt.opts)
+		if _, err := a.CheckFileSizeConcurrent([]string{u}, 1, ""); err != nil {
+			t.Fatal(err)
+		}
+		if n := a.LastStats().Requests; n != tt.requests {
+			t.Errorf("RetryNetworkErrors = %v 时请求了 %d 次，应为 %d 次", tt.opts.RetryNetworkErrors, n, tt.requests)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// checkpoint 检查点文件，第一行为格式版本，之后每完成一个 URL 追加一行 JSON（NDJSON），中断后可据此续跑
+type checkpoint struct {
+	mu   sync.Mutex
+	file *os.File
+	err  error // 第一次写入失败的原因
+}
+
+// openCheckpoint 打开检查点文件。续跑时在原文件后追加，否则清空重新记录
+func openCheckpoint(path string, resume bool) (*checkpoint, error) {
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		flag |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("打开检查点文件失败: %w", err)
+	}
+
+	// 新文件的第一行记录格式版本，之后每行一条结果
+	cp := &checkpoint{file: file}
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		header, _ := json.Marshal(checkpointHeader{Version: jsonVersion})
+		if _, err := file.Write(append(header, '\n')); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("写入检查点失败: %w", err)
+		}
+	}
+	return cp, nil
+}
+
+// checkpointHeader 检查点文件的第一行，版本号与 JSON 输出一致
+type checkpointHeader struct {
+	Version int `json:"version"`
+}
+
+// record 追加一条已完成的结果
+func (cp *checkpoint) record(r Result) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.err != nil {
+		return
+	}
+	if _, err := cp.file.Write(line); err != nil {
+		cp.err = err
+	}
+}
+
+// Close 关闭检查点文件，返回写入过程中的第一个错误
+func (cp *checkpoint) Close() error {
+	err := cp.file.Close()
+	if cp.err != nil {
+		return fmt.Errorf("写入检查点失败: %w", cp.err)
+	}
+	return err
+}
+
+// readCheckpoint 读取检查点文件中已完成的结果，文件不存在时返回空
+func readCheckpoint(path string) ([]Result, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开检查点文件失败: %w", err)
+	}
+	defer file.Close()
+
+	var results []Result
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var r Result
+		// 中断时最后一行可能只写了一半，跳过无法解析的行
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		// 版本行没有 URL
+		if r.URL == "" {
+			continue
+		}
+		results = append(results, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取检查点文件失败: %w", err)
+	}
+	return results, nil
+}
+
+// remainingTargets 去掉检查点中已完成的目标，URL 按规范形式比较
+func remainingTargets(targets []Target, done []Result, sortQuery bool) []Target {
+	completed := make(map[string]bool, len(done))
+	for _, r := range done {
+		completed[canonicalURL(r.URL, sortQuery)] = true
+	}
+
+	remaining := make([]Target, 0, len(targets))
+	for _, t := range targets {
+		if !completed[canonicalURL(t.URL, sortQuery)] {
+			remaining = append(remaining, t)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// runCLI 以无界面的命令行模式运行，返回进程退出码
+func runCLI(args []string) int {
+	flags := flag.NewFlagSet("UrlFileSizeChecker", flag.ContinueOnError)
+	configPath := flags.String("config", "", "JSON 配置文件路径")
+	input := flags.String("input", "", "URL 列表文件，每行一个 URL；为 - 或省略且标准输入不是终端时从标准输入读取")
+	output := flags.String("output", "", "输出文件路径，覆盖配置文件中的 outputFile；都未指定时只打印汇总，不写入文件（合并时必须指定）")
+	concurrency := flags.Int("concurrency", 0, "并发数，覆盖配置文件中的 concurrency")
+	checkpointFile := flags.String("checkpoint", "", "检查点文件，记录已完成的 URL")
+	resume := flags.Bool("resume", false, "从检查点续跑，只检查尚未完成的 URL")
+	progress := flags.String("progress", "lines", "进度显示方式：lines 每个 URL 一行，bar 节流刷新的单行进度条，none 不显示")
+	merge := flags.String("merge", "", "合并多个结果文件（逗号分隔）到 -output，不进行检查")
+	maxFailures := flags.Int("max-failures", -1, "失败的 URL 数超过该值时以退出码 3 结束（输出文件照常写入），0 表示有任何失败即退出 3，-1 不检查")
+	shard := flags.String("shard", "", "只检查分到本分片的 URL，格式为 分片序号/分片总数，如 0/4")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var opts Options
+	if *configPath != "" {
+		var err error
+		if opts, err = loadOptions(*configPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	// 命令行参数优先于配置文件
+	if *concurrency > 0 {
+		opts.Concurrency = *concurrency
+	}
+	if *output != "" {
+		opts.OutputFile = *output
+	}
+	if *checkpointFile != "" {
+		opts.CheckpointFile = *checkpointFile
+	}
+	if *resume {
+		opts.Resume = true
+	}
+	if *shard != "" {
+		index, count, ok := strings.Cut(*shard, "/")
+		i, err1 := strconv.Atoi(index)
+		n, err2 := strconv.Atoi(count)
+		if !ok || err1 != nil || err2 != nil {
+			fmt.Fprintf(os.Stderr, "无效的分片 %q，格式应为 分片序号/分片总数\n", *shard)
+			return 2
+		}
+		opts.ShardIndex, opts.ShardCount = i, n
+	}
+	// 命令行模式下相对路径相对于当前目录，而不是桌面
+	if opts.OutputFile != "" {
+		if abs, err := filepath.Abs(opts.OutputFile); err == nil {
+			opts.OutputFile = abs
+		}
+	}
+	if opts.CheckpointFile != "" {
+		if abs, err := filepath.Abs(opts.CheckpointFile); err == nil {
+			opts.CheckpointFile = abs
+		}
+	}
+
+	if *merge != "" {
+		if opts.OutputFile == "" {
+			fmt.Fprintln(os.Stderr, "缺少输出文件，请使用 -output 指定")
+			return 2
+		}
+		results, err := mergeResultFiles(strings.Split(*merge, ","), opts.withDefaults())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "合并失败:", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "合并完成，共 %d 条结果，已保存到 %s\n", len(results), opts.OutputFile)
+		return 0
+	}
+
+	if *input == "" {
+		if !stdinPiped() {
+			fmt.Fprintln(os.Stderr, "缺少 URL 列表，请使用 -input 指定")
+			return 2
+		}
+		*input = stdinPath
+	}
+	// 低内存模式需要读两遍输入，标准输入只能读一遍
+	if *input == stdinPath && opts.LowMemory {
+		fmt.Fprintln(os.Stderr, "低内存模式不支持从标准输入读取 URL 列表")
+		return 2
+	}
+	// 低内存模式直接逐行读取列表，不一次性载入
+	var targets []Target
+	if !opts.LowMemory {
+		var err error
+		if targets, err = loadURLsFromFile(*input); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	app := NewApp()
+	finishProgress := func() {}
+	switch *progress {
+	case "lines":
+		app.reporter = func(completed, total int, r Result) {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s %s\n", completed, total, r.URL, r.Size)
+		}
+	case "bar":
+		bar := newProgressBar()
+		finishProgress = bar.finish
+		app.reporter = func(completed, total int, _ Result) {
+			bar.update(completed, total)
+		}
+	case "none":
+	default:
+		fmt.Fprintf(os.Stderr, "未知的进度显示方式: %s\n", *progress)
+		return 2
+	}
+
+	// Ctrl-C 或 SIGTERM 与 CancelCheck 一样取消检查，已完成的部分结果仍会写入输出文件
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var err error
+	if opts.LowMemory {
+		err = app.checkLowMemory(ctx, func(fn func(Target) error) error {
+			return scanURLsFromFile(*input, fn)
+		}, opts)
+	} else {
+		app.SetOptions(opts)
+		_, err = app.checkTargetsContext(ctx, targets, 0, "")
+	}
+	finishProgress()
+	if errors.Is(err, ErrCancelled) {
+		if opts.OutputFile == "" {
+			fmt.Fprintf(os.Stderr, "检查已中断，已完成 %d 条\n", app.LastStats().Total)
+		} else {
+			fmt.Fprintf(os.Stderr, "检查已中断，部分结果（%d 条）已保存到 %s\n", app.LastStats().Total, opts.OutputFile)
+		}
+		return 130
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "检查失败:", err)
+		return 1
+	}
+
+	stats := app.LastStats()
+	saved := ""
+	if opts.OutputFile != "" {
+		saved = "，结果已保存到 " + opts.OutputFile
+	}
+	fmt.Fprintf(os.Stderr, "检查完成：成功 %d，失败 %d，总大小 %s，耗时 %s，请求 %d 次，下载 %s%s\n",
+		stats.Succeeded, stats.Failed, formatFileSize(stats.TotalBytes),
+		time.Duration(stats.Elapsed).Round(time.Millisecond), stats.Requests, formatFileSize(stats.BytesRead),
+		saved)
+	if summary := stats.failureSummary(); summary != "" {
+		fmt.Fprintf(os.Stderr, "失败原因：%s\n", summary)
+	}
+	if stats.EstimatedDownload > 0 {
+		fmt.Fprintf(os.Stderr, "按 %s/s 估算，下载全部文件约需 %s\n", formatFileSize(opts.AssumedBandwidth), time.Duration(stats.EstimatedDownload).Round(time.Second))
+	}
+	if len(stats.TrippedHosts) > 0 {
+		fmt.Fprintf(os.Stderr, "熔断的主机：%s\n", strings.Join(stats.TrippedHosts, "，"))
+	}
+	if *maxFailures >= 0 && stats.Failed > *maxFailures {
+		fmt.Fprintf(os.Stderr, "失败 %d 个，超过允许的 %d 个\n", stats.Failed, *maxFailures)
+		return 3
+	}
+	return 0
+}
+
+// stdinPiped 判断标准输入是否来自管道或重定向的文件，而不是终端
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestRunCLISummaryOnly(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(1024))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "urls.txt")
+	if err := os.WriteFile(input, []byte(srv.URL+"/a\n"+srv.URL+"/b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	if code := runCLI([]string{"-input", input, "-progress", "none"}); code != 0 {
+		t.Fatalf("退出码 %d，应为 0", code)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("未指定输出文件时不应写入文件，目录中有 %d 个文件", len(entries))
+	}
+}
+
+func TestCheckWithoutOutputFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "10")
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	a := NewApp()
+	results, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/a"}, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Bytes != 10 {
+		t.Fatalf("results = %+v", results)
+	}
+	if stats := a.LastStats(); stats.Succeeded != 1 || stats.TotalBytes != 10 {
+		t.Fatalf("LastStats = %+v", stats)
+	}
+	entries, err := os.ReadDir(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("outputFile 为空时不应写入文件，主目录中有 %d 个文件", len(entries))
+	}
+}
@@ -0,0 +1,635 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// column 输出文件中的一列
+type column struct {
+	header  string                          // 表头
+	value   func(r Result) interface{}      // 从结果中取值
+	sortKey func(r Result) interface{}      // 排序依据，为空时按显示的值排序
+	parse   func(r *Result, s string) error // 读取已有结果文件时，将单元格文本写回结果
+	grouped bool                            // 开启 GroupDigits 时文本输出中加千位分隔符，parse 须能去掉分隔符
+}
+
+// knownColumns 可输出的全部列，读取结果文件时也按表头在这里查找
+var knownColumns = []column{
+	{
+		header: "URL",
+		value:  func(r Result) interface{} { return r.URL },
+		parse:  func(r *Result, s string) error { r.URL = s; return nil },
+	},
+	{
+		header: "输入位置",
+		value:  func(r Result) interface{} { return r.Index },
+		parse: func(r *Result, s string) error {
+			if s == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(s)
+			r.Index = n
+			return err
+		},
+	},
+	{
+		header:  "文件大小",
+		value:   func(r Result) interface{} { return r.Size },
+		sortKey: func(r Result) interface{} { return r.Bytes },
+		parse: func(r *Result, s string) error {
+			r.Size = s
+			if s == "获取失败" || s == errHostFiltered.Error() || s == errHostTripped.Error() || s == errBodyLimit.Error() {
+				r.Err = s
+			} else if r.Bytes == 0 {
+				r.Bytes = parseSize(s)
+			}
+			return nil
+		},
+	},
+	{
+		header: "状态码",
+		value: func(r Result) interface{} {
+			if r.StatusCode == 0 {
+				return ""
+			}
+			return r.StatusCode
+		},
+		parse: func(r *Result, s string) error {
+			if s == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(s)
+			r.StatusCode = n
+			return err
+		},
+	},
+	{
+		header: "内容类型",
+		value:  func(r Result) interface{} { return r.ContentType },
+		parse:  func(r *Result, s string) error { r.ContentType = s; return nil },
+	},
+	{
+		header: "失败原因",
+		value:  func(r Result) interface{} { return r.Err },
+		parse: func(r *Result, s string) error {
+			if s != "" {
+				r.Err = s
+			}
+			return nil
+		},
+	},
+	{
+		header:  "字节数",
+		value:   func(r Result) interface{} { return r.Bytes },
+		grouped: true,
+		parse: func(r *Result, s string) error {
+			// 去掉可能存在的千位分隔符
+			digits := strings.Map(func(c rune) rune {
+				if c >= '0' && c <= '9' {
+					return c
+				}
+				return -1
+			}, s)
+			if digits == "" {
+				return nil
+			}
+			n, err := strconv.ParseInt(digits, 10, 64)
+			r.Bytes = n
+			return err
+		},
+	},
+	{
+		header: "最终URL",
+		value:  func(r Result) interface{} { return r.FinalURL },
+		parse:  func(r *Result, s string) error { r.FinalURL = s; return nil },
+	},
+	{
+		header: "疑似软失败",
+		value:  func(r Result) interface{} { return yesNo(r.SuspectSoftFail) },
+		parse:  func(r *Result, s string) error { r.SuspectSoftFail = s == "是"; return nil },
+	},
+	{
+		header: "跨域名重定向",
+		value:  func(r Result) interface{} { return yesNo(r.CrossHostRedirect) },
+		parse:  func(r *Result, s string) error { r.CrossHostRedirect = s == "是"; return nil },
+	},
+	{
+		header: "重定向次数",
+		value:  func(r Result) interface{} { return r.RedirectCount },
+		parse: func(r *Result, s string) error {
+			if s == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(s)
+			r.RedirectCount = n
+			return err
+		},
+	},
+	{
+		header: "下载不完整",
+		value:  func(r Result) interface{} { return yesNo(r.Truncated) },
+		parse:  func(r *Result, s string) error { r.Truncated = s == "是"; return nil },
+	},
+	{
+		header: "声明支持Range",
+		value:  func(r Result) interface{} { return yesNo(r.AcceptRanges) },
+		parse:  func(r *Result, s string) error { r.AcceptRanges = s == "是"; return nil },
+	},
+	{
+		header: "Range可用",
+		value:  func(r Result) interface{} { return yesNo(r.RangeActuallyWorks) },
+		parse:  func(r *Result, s string) error { r.RangeActuallyWorks = s == "是"; return nil },
+	},
+	{
+		header: "内容编码",
+		value:  func(r Result) interface{} { return r.ContentEncoding },
+		parse: func(r *Result, s string) error {
+			r.ContentEncoding = s
+			return nil
+		},
+	},
+	{
+		header: "传输字节数",
+		value:  func(r Result) interface{} { return blankZero(r.TransferBytes) },
+		parse: func(r *Result, s string) error {
+			n, err := parseOptionalInt(s)
+			r.TransferBytes = n
+			return err
+		},
+	},
+	{
+		header: "解压后字节数",
+		value:  func(r Result) interface{} { return blankZero(r.DecompressedBytes) },
+		parse: func(r *Result, s string) error {
+			n, err := parseOptionalInt(s)
+			r.DecompressedBytes = n
+			return err
+		},
+	},
+	{
+		header: "压缩比",
+		value: func(r Result) interface{} {
+			if r.CompressionRatio == 0 {
+				return ""
+			}
+			return strconv.FormatFloat(r.CompressionRatio, 'f', 2, 64)
+		},
+		sortKey: func(r Result) interface{} { return r.CompressionRatio },
+		parse: func(r *Result, s string) error {
+			if s == "" {
+				return nil
+			}
+			f, err := strconv.ParseFloat(s, 64)
+			r.CompressionRatio = f
+			return err
+		},
+	},
+	{
+		header: "最小字节数",
+		value:  func(r Result) interface{} { return blankZero(r.MinBytes) },
+		parse: func(r *Result, s string) error {
+			n, err := parseOptionalInt(s)
+			r.MinBytes = n
+			return err
+		},
+	},
+	{
+		header: "最大字节数",
+		value:  func(r Result) interface{} { return blankZero(r.MaxBytes) },
+		parse: func(r *Result, s string) error {
+			n, err := parseOptionalInt(s)
+			r.MaxBytes = n
+			return err
+		},
+	},
+	{
+		header: "众数字节数",
+		value:  func(r Result) interface{} { return blankZero(r.ModeBytes) },
+		parse: func(r *Result, s string) error {
+			n, err := parseOptionalInt(s)
+			r.ModeBytes = n
+			return err
+		},
+	},
+	{
+		header: "大小稳定",
+		value:  func(r Result) interface{} { return yesNo(r.Stable) },
+		parse:  func(r *Result, s string) error { r.Stable = s == "是"; return nil },
+	},
+	{
+		header: "预期字节数",
+		value:  func(r Result) interface{} { return blankZero(r.ExpectedBytes) },
+		parse: func(r *Result, s string) error {
+			n, err := parseOptionalInt(s)
+			r.ExpectedBytes = n
+			return err
+		},
+	},
+	{
+		header: "大小差值",
+		value: func(r Result) interface{} {
+			// 差值为 0 也有意义，只在没有预期大小时留空
+			if r.ExpectedBytes == 0 {
+				return ""
+			}
+			return r.SizeDelta
+		},
+		sortKey: func(r Result) interface{} { return r.SizeDelta },
+		parse: func(r *Result, s string) error {
+			n, err := parseOptionalInt(s)
+			r.SizeDelta = n
+			return err
+		},
+	},
+	{
+		header: "大小不符",
+		value:  func(r Result) interface{} { return yesNo(r.SizeMismatch) },
+		parse:  func(r *Result, s string) error { r.SizeMismatch = s == "是"; return nil },
+	},
+	{
+		header: "ETag",
+		value:  func(r Result) interface{} { return r.ETag },
+		parse:  func(r *Result, s string) error { r.ETag = s; return nil },
+	},
+	{
+		header: "重复组",
+		value: func(r Result) interface{} {
+			if r.DupGroup == 0 {
+				return ""
+			}
+			return r.DupGroup
+		},
+		parse: func(r *Result, s string) error {
+			if s == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(s)
+			r.DupGroup = n
+			return err
+		},
+	},
+	{
+		header: "同一最终URL组",
+		value: func(r Result) interface{} {
+			if r.FinalGroup == 0 {
+				return ""
+			}
+			return r.FinalGroup
+		},
+		parse: func(r *Result, s string) error {
+			if s == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(s)
+			r.FinalGroup = n
+			return err
+		},
+	},
+	{
+		header: "另一协议URL",
+		value: func(r Result) interface{} {
+			if r.Alt == nil {
+				return ""
+			}
+			return r.Alt.URL
+		},
+		parse: func(r *Result, s string) error { altResult(r).URL = s; return nil },
+	},
+	{
+		header: "另一协议大小",
+		value: func(r Result) interface{} {
+			if r.Alt == nil {
+				return ""
+			}
+			return r.Alt.Size
+		},
+		parse: func(r *Result, s string) error {
+			alt := altResult(r)
+			alt.Size = s
+			if s == "获取失败" {
+				alt.Err = s
+			} else {
+				alt.Bytes = parseSize(s)
+			}
+			return nil
+		},
+	},
+	{
+		header: "协议间大小一致",
+		value:  func(r Result) interface{} { return yesNo(r.SchemeMatch) },
+		parse:  func(r *Result, s string) error { r.SchemeMatch = s == "是"; return nil },
+	},
+	{
+		header: "备注",
+		value:  func(r Result) interface{} { return r.Note },
+		parse:  func(r *Result, s string) error { r.Note = s; return nil },
+	},
+	{
+		header: "明文HTTP",
+		value:  func(r Result) interface{} { return yesNo(r.Insecure) },
+		parse:  func(r *Result, s string) error { r.Insecure = s == "是"; return nil },
+	},
+	{
+		header: "达到大小上限",
+		value:  func(r Result) interface{} { return yesNo(r.HitSizeCap) },
+		parse:  func(r *Result, s string) error { r.HitSizeCap = s == "是"; return nil },
+	},
+	{
+		header: "协议版本",
+		value:  func(r Result) interface{} { return r.Proto },
+		parse:  func(r *Result, s string) error { r.Proto = s; return nil },
+	},
+	{
+		header: "服务器IP",
+		value:  func(r Result) interface{} { return r.RemoteAddr },
+		parse:  func(r *Result, s string) error { r.RemoteAddr = s; return nil },
+	},
+	{
+		header: "尝试记录",
+		value: func(r Result) interface{} {
+			parts := make([]string, len(r.Attempts))
+			for i, a := range r.Attempts {
+				parts[i] = a.String()
+			}
+			return strings.Join(parts, ", ")
+		},
+		parse: func(r *Result, s string) error {
+			r.Attempts = parseAttempts(s)
+			return nil
+		},
+	},
+	{
+		header: "取大小方式",
+		value:  func(r Result) interface{} { return r.SizeMethod },
+		parse:  func(r *Result, s string) error { r.SizeMethod = s; return nil },
+	},
+	{
+		header:  "耗时",
+		value:   func(r Result) interface{} { return time.Duration(r.Elapsed).Round(time.Millisecond).String() },
+		sortKey: func(r Result) interface{} { return int64(r.Elapsed) },
+		parse: func(r *Result, s string) error {
+			if s == "" {
+				return nil
+			}
+			d, err := time.ParseDuration(s)
+			r.Elapsed = Duration(d)
+			return err
+		},
+	},
+	{
+		header: "检查时间",
+		value:  func(r Result) interface{} { return formatTime(r.CheckedAt) },
+		parse:  func(r *Result, s string) error { return parseCheckedAt(r, s, "") },
+	},
+}
+
+// columnByHeader 按表头查找列
+func columnByHeader(header string) (column, bool) {
+	for _, col := range knownColumns {
+		if col.header == header {
+			return col, true
+		}
+	}
+	return column{}, false
+}
+
+// outputColumns 根据选项确定输出的列，默认只有 URL 和文件大小。
+// 指定了 Columns 时按其中的表头和顺序输出，不再根据其他选项添加列
+func outputColumns(opts Options) []column {
+	columns := selectColumns(opts)
+	if opts.TimeFormat != "" {
+		for i, col := range columns {
+			if col.header == "检查时间" {
+				columns[i].value = func(r Result) interface{} { return formatTimeLayout(r.CheckedAt, opts.TimeFormat) }
+			}
+		}
+	}
+	if opts.SizeUnit != "" {
+		for i, col := range columns {
+			switch col.header {
+			case "文件大小", "另一协议大小":
+				columns[i].header = fmt.Sprintf("%s(%s)", col.header, opts.SizeUnit)
+			}
+			switch col.header {
+			case "文件大小":
+				columns[i].value = func(r Result) interface{} { return fixedUnitSize(r, opts) }
+			case "另一协议大小":
+				columns[i].value = func(r Result) interface{} {
+					if r.Alt == nil {
+						return ""
+					}
+					return fixedUnitSize(*r.Alt, opts)
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// parseAttempts 解析尝试记录列，格式同 Attempt.String，多项以逗号分隔。
+// 从文本中读回时只保留方式、状态码和失败原因，失败原因中的逗号会被当作分隔符
+func parseAttempts(s string) []Attempt {
+	var attempts []Attempt
+	for _, part := range strings.Split(s, ", ") {
+		step, outcome, ok := strings.Cut(part, ": ")
+		if !ok {
+			continue
+		}
+		a := Attempt{Strategy: SizeStrategy(step)}
+		if outcome != "ok" {
+			if code, err := strconv.Atoi(outcome); err == nil {
+				a.StatusCode = code
+				a.Err = (&statusError{code: code}).Error()
+			} else {
+				a.Err = outcome
+			}
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts
+}
+
+// fixedUnitSize 成功的结果返回按 SizeUnit 换算的数值，失败的结果仍输出失败原因（如“获取失败”）
+func fixedUnitSize(r Result, opts Options) interface{} {
+	if r.Failed() {
+		return r.Size
+	}
+	precision := defaultSizePrecision
+	if opts.SizePrecision != nil {
+		precision = *opts.SizePrecision
+	}
+	return fileSizeIn(r.Bytes, opts.SizeUnit, precision)
+}
+
+// selectColumns 按选项选出要输出的列
+func selectColumns(opts Options) []column {
+	if len(opts.Columns) > 0 {
+		columns := make([]column, 0, len(opts.Columns))
+		for _, h := range opts.Columns {
+			// 表头已在 validate 中校验过
+			col, _ := columnByHeader(h)
+			columns = append(columns, col)
+		}
+		return columns
+	}
+
+	headers := []string{"URL", "文件大小"}
+	if opts.IndexColumn {
+		headers = []string{"输入位置", "URL", "文件大小"}
+	}
+	if opts.BytesColumn {
+		headers = append(headers, "字节数")
+	}
+	if opts.ProtoColumn {
+		headers = append(headers, "协议版本")
+	}
+	if opts.RemoteAddrColumn {
+		headers = append(headers, "服务器IP")
+	}
+	if opts.InsecureColumn {
+		headers = append(headers, "明文HTTP")
+	}
+	if opts.SoftFailCheck {
+		headers = append(headers, "最终URL", "疑似软失败")
+	}
+	if opts.RedirectColumns {
+		headers = append(headers, "最终URL", "跨域名重定向", "重定向次数")
+	}
+	if opts.Repeat > 1 {
+		headers = append(headers, "最小字节数", "最大字节数", "众数字节数", "大小稳定")
+	}
+	if opts.ExpectedSizeColumns {
+		headers = append(headers, "预期字节数", "大小差值", "大小不符")
+	}
+	if opts.MeasureCompression {
+		headers = append(headers, "内容编码", "传输字节数", "解压后字节数", "压缩比")
+	}
+	if opts.RangeCheck {
+		headers = append(headers, "声明支持Range", "Range可用")
+	}
+	if opts.DuplicateCheck {
+		headers = append(headers, "ETag", "重复组")
+	}
+	if opts.SameFinalCheck {
+		headers = append(headers, "最终URL", "同一最终URL组")
+	}
+	if opts.CompareSchemes {
+		headers = append(headers, "另一协议URL", "另一协议大小", "协议间大小一致")
+	}
+	switch {
+	case len(opts.Strategies) > 0:
+		headers = append(headers, "取大小方式", "尝试记录")
+		if slices.Contains(opts.Strategies, StrategyGet) {
+			headers = append(headers, "下载不完整", "备注")
+		}
+	case opts.SizeStrategy == StrategyGet:
+		headers = append(headers, "下载不完整", "备注")
+	case opts.SizeStrategy == StrategyAuto:
+		headers = append(headers, "取大小方式", "尝试记录", "下载不完整", "备注")
+	case opts.SizeStrategy == StrategyHead && opts.GetOnEmptyHead:
+		headers = append(headers, "取大小方式")
+	}
+	if opts.MaxBodyBytes > 0 {
+		headers = append(headers, "达到大小上限")
+	}
+	if opts.SortBy == SortByDuration {
+		headers = append(headers, "耗时")
+	}
+	if opts.TimestampColumn {
+		headers = append(headers, "检查时间")
+	}
+
+	// 多个选项可能包含同一列，只输出一次
+	seen := make(map[string]bool)
+	columns := make([]column, 0, len(headers))
+	for _, h := range headers {
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+		col, _ := columnByHeader(h)
+		columns = append(columns, col)
+	}
+	return columns
+}
+
+// blankZero 零值显示为空，用于未测量的数值
+func blankZero(n int64) interface{} {
+	if n == 0 {
+		return ""
+	}
+	return n
+}
+
+// parseOptionalInt 解析可能为空的整数单元格
+func parseOptionalInt(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// altResult 返回另一协议的结果，不存在时创建
+func altResult(r *Result) *Result {
+	if r.Alt == nil {
+		r.Alt = &Result{}
+	}
+	return r.Alt
+}
+
+// yesNo 将布尔值显示为 是/否
+func yesNo(b bool) string {
+	if b {
+		return "是"
+	}
+	return "否"
+}
+
+// formatTime 按 RFC3339 格式化时间，零值输出为空
+func formatTime(t time.Time) string {
+	return formatTimeLayout(t, "")
+}
+
+// formatTimeLayout 按 layout 格式化时间，layout 为空时使用 RFC3339，零值输出为空
+func formatTimeLayout(t time.Time, layout string) string {
+	if t.IsZero() {
+		return ""
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return t.Format(layout)
+}
+
+// parseCheckedAt 解析检查时间列，先按 layout（写入时的 TimeFormat，不含时区时按本地时间）解析，
+// layout 为空或解析失败时按 RFC3339 解析，兼容用默认格式写入的文件
+func parseCheckedAt(r *Result, s, layout string) error {
+	if s == "" {
+		return nil
+	}
+	if layout != "" {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			r.CheckedAt = t
+			return nil
+		}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	r.CheckedAt = t
+	return err
+}
+
+// validTimeLayout 判断 layout 是否是有效的 Go 时间格式：至少含有一个格式元素，
+// 且按它格式化的时间能按它解析回来。用于检验的时间不能是参考时间本身，否则格式化后与 layout 相同
+func validTimeLayout(layout string) bool {
+	sample := time.Date(2009, 11, 10, 23, 7, 8, 0, time.UTC)
+	formatted := sample.Format(layout)
+	if formatted == layout {
+		return false
+	}
+	_, err := time.Parse(layout, formatted)
+	return err == nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/message"
+)
+
+// utf8BOM UTF-8 字节顺序标记，Excel 据此识别 CSV 的编码，否则中文会显示为乱码
+const utf8BOM = "\ufeff"
+
+// writeToCSV 将结果按列写入 CSV 文件，comma 为分隔符（TSV 使用 '\t'）。
+// 包含分隔符、引号或换行的字段由 encoding/csv 自动加引号。bom 为 true 时在文件开头写入 UTF-8 BOM
+func writeToCSV(results []Result, columns []column, outputPath string, printer *message.Printer, comma rune, bom bool) error {
+	file, err := createOutput(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if bom {
+		if _, err := io.WriteString(file, utf8BOM); err != nil {
+			return err
+		}
+	}
+	w := csv.NewWriter(file)
+	w.Comma = comma
+	record := make([]string, len(columns))
+	for j, col := range columns {
+		record[j] = col.header
+	}
+	if err := w.Write(record); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		for j, col := range columns {
+			record[j] = cellText(col, r, printer)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", outputFormat(outputPath), err)
+	}
+	return file.Close()
+}
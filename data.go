@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// isDataURI 判断是否为 data: URI，协议名不区分大小写
+func isDataURI(raw string) bool {
+	return len(raw) >= 5 && strings.EqualFold(raw[:5], "data:")
+}
+
+// decodeDataURI 解码 data: URI（RFC 2397），返回内容和媒体类型。
+// 内容为 base64 或百分号编码，媒体类型省略时为 text/plain;charset=US-ASCII
+func decodeDataURI(raw string) ([]byte, string, error) {
+	meta, payload, ok := strings.Cut(raw[len("data:"):], ",")
+	if !ok {
+		return nil, "", errors.New("data URI 缺少逗号分隔的内容")
+	}
+
+	base64Encoded := false
+	if i := strings.LastIndex(meta, ";"); i >= 0 && strings.EqualFold(meta[i+1:], "base64") {
+		base64Encoded = true
+		meta = meta[:i]
+	}
+	if meta == "" || strings.HasPrefix(meta, ";") {
+		meta = "text/plain" + meta
+		if !strings.Contains(strings.ToLower(meta), "charset=") {
+			meta += ";charset=US-ASCII"
+		}
+	}
+
+	// base64 内容中也可能有百分号编码，先统一解码
+	data, err := url.PathUnescape(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("data URI 百分号编码无效: %w", err)
+	}
+	if !base64Encoded {
+		return []byte(data), meta, nil
+	}
+
+	// 容忍换行、空格和缺少的填充
+	data = strings.Map(func(c rune) rune {
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			return -1
+		}
+		return c
+	}, data)
+	decoded, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+	if err != nil {
+		return nil, "", fmt.Errorf("data URI base64 内容无效: %w", err)
+	}
+	return decoded, meta, nil
+}
+
+// dataSize 返回 data: URI 解码后的字节数，不发起任何网络请求
+func dataSize(t Target) (Result, error) {
+	var r Result
+
+	data, mediaType, err := decodeDataURI(t.URL)
+	if err != nil {
+		return r, err
+	}
+
+	r.ContentType = mediaType
+	r.Bytes = int64(len(data))
+	r.Size = formatFileSize(r.Bytes)
+	return r, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+// dupKey 判断重复文件所用的键
+type dupKey struct {
+	bytes int64
+	etag  string
+}
+
+// markDuplicates 将大小和 ETag 都相同的成功结果标记为疑似重复，组号从 1 开始，
+// 按结果的顺序分配。没有 ETag 的结果不参与判断
+func markDuplicates(results []Result) {
+	counts := make(map[dupKey]int)
+	for _, r := range results {
+		if !r.Failed() && r.ETag != "" {
+			counts[dupKey{r.Bytes, r.ETag}]++
+		}
+	}
+
+	groups := make(map[dupKey]int)
+	for i, r := range results {
+		// 清除之前标记的组号，如重新检查后合并的结果
+		results[i].DupGroup = 0
+		key := dupKey{r.Bytes, r.ETag}
+		if r.Failed() || r.ETag == "" || counts[key] < 2 {
+			continue
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = len(groups) + 1
+			groups[key] = group
+		}
+		results[i].DupGroup = group
+	}
+}
+
+// markSameFinal 将跟随重定向后到达同一最终 URL（按规范形式比较）的不同输入 URL 编为一组，组号从 1 开始，
+// 按结果的顺序分配。同一 URL 重复输入不算，没有最终 URL 的结果（请求未完成或不是 HTTP）不参与判断
+func markSameFinal(results []Result, sortQuery bool) {
+	inputs := make(map[string]map[string]bool)
+	for _, r := range results {
+		if r.FinalURL == "" {
+			continue
+		}
+		final := canonicalURL(r.FinalURL, sortQuery)
+		if inputs[final] == nil {
+			inputs[final] = make(map[string]bool)
+		}
+		inputs[final][canonicalURL(r.URL, sortQuery)] = true
+	}
+
+	groups := make(map[string]int)
+	for i, r := range results {
+		results[i].FinalGroup = 0
+		if r.FinalURL == "" {
+			continue
+		}
+		final := canonicalURL(r.FinalURL, sortQuery)
+		if len(inputs[final]) < 2 {
+			continue
+		}
+		group, ok := groups[final]
+		if !ok {
+			group = len(groups) + 1
+			groups[final] = group
+		}
+		results[i].FinalGroup = group
+	}
+}
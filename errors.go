@@ -0,0 +1,37 @@
+package main
+
+import "errors"
+
+// 检查可能返回的错误，返回时会包装具体原因，可用 errors.Is 判断。
+// 单个 URL 的失败不会作为错误返回，而是记录在 Result.Err 中
+var (
+	ErrCancelled      = errors.New("检查已取消")      // 调用了 CancelCheck 或外部 context 被取消
+	ErrNoURLs         = errors.New("没有要检查的 URL") // 输入为空
+	ErrInvalidOptions = errors.New("选项无效")       // 选项取值不合法
+	ErrWriteOutput    = errors.New("写入输出文件失败")   // 输出文件或检查点写入失败
+)
+
+// 错误类型，随 error 事件发送，供前端按类型显示本地化的提示
+const (
+	KindCancelled      = "cancelled"
+	KindNoURLs         = "noUrls"
+	KindInvalidOptions = "invalidOptions"
+	KindWriteOutput    = "writeOutput"
+	KindOther          = "other"
+)
+
+// errorKind 返回错误对应的类型
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrCancelled):
+		return KindCancelled
+	case errors.Is(err, ErrNoURLs):
+		return KindNoURLs
+	case errors.Is(err, ErrInvalidOptions):
+		return KindInvalidOptions
+	case errors.Is(err, ErrWriteOutput):
+		return KindWriteOutput
+	default:
+		return KindOther
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// fileSize 通过 os.Stat 获取 file:// URL 指向的本地文件大小。
+// 文件不存在时与 HTTP 404 返回相同形式的失败结果
+func fileSize(t Target) (Result, error) {
+	var r Result
+
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return r, err
+	}
+
+	path := u.Path
+	// Windows 下 file:///C:/dir/file 的路径形如 /C:/dir/file
+	if runtime.GOOS == "windows" && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	path = filepath.FromSlash(path)
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		r.StatusCode = http.StatusNotFound
+		return r, &statusError{code: http.StatusNotFound}
+	}
+	if err != nil {
+		return r, err
+	}
+	if info.IsDir() {
+		return r, errors.New("路径是目录而不是文件")
+	}
+
+	r.StatusCode = http.StatusOK
+	r.FinalURL = t.URL
+	r.Bytes = info.Size()
+	r.Size = formatFileSize(info.Size())
+	return r, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// partialWriter 检查过程中定期把已完成的结果写入输出文件，中途崩溃或中断时仍留下可用的文件
+type partialWriter struct {
+	path  string
+	opts  Options
+	start time.Time
+	warn  func(string)
+
+	mu      sync.Mutex
+	results []Result  // 之前的结果和本次已完成的结果
+	pending int       // 上次写入后新完成的数量
+	last    time.Time // 上次写入的时间
+	writing bool      // 正在写入，期间完成的结果留到下一次
+	wg      sync.WaitGroup
+}
+
+// newPartialWriter 设置了 FlushEvery 或 FlushInterval 且有输出文件时创建，否则返回 nil。
+// SQLite 结果库会保留之前各次检查的记录，用中间结果替换整个文件会丢掉这些记录，
+// 最终写入时本次的结果还会再追加一遍，因此不写中间结果，进度改由检查点记录
+func newPartialWriter(prior []Result, path string, opts Options, start time.Time, warn func(string)) *partialWriter {
+	if path == "" || (opts.FlushEvery <= 0 && opts.FlushInterval <= 0) {
+		return nil
+	}
+	switch outputFormat(path) {
+	case ".db", ".sqlite":
+		warn("SQLite 输出不写入中间结果，已忽略 flushEvery 和 flushInterval，需要记录进度时请使用 checkpointFile")
+		return nil
+	}
+	return &partialWriter{
+		path:    path,
+		opts:    opts,
+		start:   start,
+		warn:    warn,
+		results: append([]Result(nil), prior...),
+		last:    start,
+	}
+}
+
+// add 记录一个完成的结果，达到 FlushEvery 个或距上次写入超过 FlushInterval 时写入一次。
+// 写入在调用方的 goroutine 中进行，同一时间只有一次写入，其他 worker 不等待
+func (p *partialWriter) add(r Result) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.results = append(p.results, r)
+	p.pending++
+	due := p.opts.FlushEvery > 0 && p.pending >= p.opts.FlushEvery ||
+		p.opts.FlushInterval > 0 && time.Since(p.last) >= time.Duration(p.opts.FlushInterval)
+	if !due || p.writing {
+		p.mu.Unlock()
+		return
+	}
+	snapshot := append([]Result(nil), p.results...)
+	p.pending = 0
+	p.last = time.Now()
+	p.writing = true
+	p.wg.Add(1)
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.writing = false
+		p.mu.Unlock()
+		p.wg.Done()
+	}()
+	if err := p.write(snapshot); err != nil {
+		p.warn(fmt.Sprintf("写入中间结果失败: %v", err))
+	}
+}
+
+// wait 等待正在进行的写入完成，写入最终结果前调用，避免中间结果覆盖最终结果
+func (p *partialWriter) wait() {
+	if p == nil {
+		return
+	}
+	p.wg.Wait()
+}
+
+// write 排序后写入同一目录的临时文件再替换输出文件，打开输出文件时不会看到写了一半的内容。
+// 中间结果不含按类型、按主机汇总和重复标记，最终结果写入时补全
+func (p *partialWriter) write(results []Result) error {
+	if !p.opts.SkipSort {
+		sortResults(results, p.opts.SortBy, p.opts.SortOrder)
+	}
+	stats := computeStats(results, time.Since(p.start))
+	stats.StartedAt = p.start
+
+	// 加前缀而不是改扩展名，保留 .csv.gz 这样的复合扩展名
+	tmp := filepath.Join(filepath.Dir(p.path), "partial-"+filepath.Base(p.path))
+	if err := writeOutput(topResults(filterResults(results, p.opts.OutputFilter), p.opts.TopN), stats, nil, nil, tmp, p.opts); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
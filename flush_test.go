@@ -0,0 +1,36 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFlushEverySQLiteKeepsEarlierRuns(t *testing.T) {
+	srv := newSizeServer(t)
+	urls := []string{srv.URL + "/size/1", srv.URL + "/size/2", srv.URL + "/size/3"}
+	out := filepath.Join(t.TempDir(), "out.db")
+
+	// 两次检查不同的 URL，写入同一个结果库
+	for run, batch := range [][]string{urls[:2], urls[2:]} {
+		a := NewApp()
+		a.SetOptions(Options{FlushEvery: 1})
+		if _, err := a.CheckFileSizeConcurrent(batch, 1, out); err != nil {
+			t.Fatalf("第 %d 次检查: %v", run+1, err)
+		}
+	}
+
+	results, err := readFromSQLite(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 两次检查的记录都保留，每条只写入一次
+	count := make(map[string]int)
+	for _, r := range results {
+		count[r.URL]++
+	}
+	for _, u := range urls {
+		if count[u] != 1 {
+			t.Errorf("%s 有 %d 条记录，应为 1 条", u, count[u])
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+)
+
+// ftpSize 通过 FTP 的 SIZE 命令获取文件大小，用户名和密码取自 URL，缺省时匿名登录
+func (c *checker) ftpSize(ctx context.Context, t Target) (Result, error) {
+	var r Result
+
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return r, err
+	}
+
+	timeout := time.Duration(c.opts.Timeout)
+	if t.Timeout > 0 {
+		timeout = time.Duration(t.Timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := c.waitHost(ctx, u.Host); err != nil {
+		return r, err
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "21")
+	}
+	conn, err := ftp.Dial(addr, ftp.DialWithContext(ctx), ftp.DialWithTimeout(time.Duration(c.opts.ConnectTimeout)))
+	if err != nil {
+		return r, err
+	}
+	// 超时或取消时关闭连接，中断阻塞中的命令
+	stop := context.AfterFunc(ctx, func() { conn.Quit() })
+	defer func() {
+		if stop() {
+			conn.Quit()
+		}
+	}()
+
+	user, password := "anonymous", "anonymous"
+	if u.User != nil {
+		user = u.User.Username()
+		password, _ = u.User.Password()
+	}
+	if err := conn.Login(user, password); err != nil {
+		return r, err
+	}
+
+	// 部分服务器只在二进制模式下支持 SIZE
+	if err := conn.Type(ftp.TransferTypeBinary); err != nil {
+		return r, err
+	}
+	size, err := conn.FileSize(u.Path)
+	if err != nil {
+		return r, err
+	}
+
+	r.FinalURL = t.URL
+	r.Bytes = size
+	r.Size = formatFileSize(size)
+	return r, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gzipSuffix 输出文件以它结尾时（如 results.csv.gz）边写边用 gzip 压缩
+const gzipSuffix = ".gz"
+
+// outputFormat 返回决定输出格式的扩展名（小写），.gz 结尾时取去掉 .gz 后的扩展名
+func outputFormat(path string) string {
+	lower := strings.ToLower(path)
+	return filepath.Ext(strings.TrimSuffix(lower, gzipSuffix))
+}
+
+// gzipped 判断路径是否以 .gz 结尾
+func gzipped(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), gzipSuffix)
+}
+
+// checkGzipFormat 只有文本格式（CSV、TSV、JSON、HTML）可以压缩。Excel 本身已是 zip 压缩的，
+// SQLite 需要随机读写，都不支持 .gz
+func checkGzipFormat(path string) error {
+	if !gzipped(path) {
+		return nil
+	}
+	switch ext := outputFormat(path); ext {
+	case ".csv", ".tsv", ".json", ".html", ".htm":
+		return nil
+	default:
+		return fmt.Errorf("只有 CSV、TSV、JSON 和 HTML 输出支持 gzip 压缩，不支持 %s%s", ext, gzipSuffix)
+	}
+}
+
+// gzipFile 写入时压缩的文件，Close 时先结束 gzip 流再关闭文件
+type gzipFile struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	if err := g.Writer.Close(); err != nil {
+		g.file.Close()
+		return err
+	}
+	return g.file.Close()
+}
+
+// createOutput 创建输出文件，路径以 .gz 结尾时返回的 Writer 会用 gzip 压缩写入的内容
+func createOutput(path string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if !gzipped(path) {
+		return file, nil
+	}
+	return &gzipFile{Writer: gzip.NewWriter(file), file: file}, nil
+}
+
+// gzipReader 读取时解压的文件，Close 时同时关闭文件
+type gzipReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipReader) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// openInput 打开结果文件，路径以 .gz 结尾时透明解压
+func openInput(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !gzipped(path) {
+		return file, nil
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("解压 %s 失败: %w", path, err)
+	}
+	return &gzipReader{Reader: gz, file: file}, nil
+}
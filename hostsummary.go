@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 无法解析主机时使用的分组名
+const unknownHost = "(未知)"
+
+// HostSummary 按主机汇总的统计
+type HostSummary struct {
+	Host        string  // URL 中的主机（含端口）
+	Count       int     // URL 数
+	Succeeded   int     // 成功数
+	Failed      int     // 失败数
+	SuccessRate float64 // 成功率，0 到 1
+	TotalBytes  int64   // 成功结果的文件大小合计
+}
+
+// summarizeByHost 按 url.Host 汇总全部结果，成功率低的主机排在前面，便于找出不稳定的镜像
+func summarizeByHost(results []Result) []HostSummary {
+	index := make(map[string]int)
+	var summaries []HostSummary
+
+	for _, r := range results {
+		host := unknownHost
+		if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		i, ok := index[host]
+		if !ok {
+			i = len(summaries)
+			index[host] = i
+			summaries = append(summaries, HostSummary{Host: host})
+		}
+		summaries[i].Count++
+		if r.Failed() {
+			summaries[i].Failed++
+		} else {
+			summaries[i].Succeeded++
+			summaries[i].TotalBytes += r.Bytes
+		}
+	}
+
+	for i := range summaries {
+		summaries[i].SuccessRate = float64(summaries[i].Succeeded) / float64(summaries[i].Count)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].SuccessRate != summaries[j].SuccessRate {
+			return summaries[i].SuccessRate < summaries[j].SuccessRate
+		}
+		return summaries[i].Host < summaries[j].Host
+	})
+	return summaries
+}
+
+// writeHostSheet 将按主机汇总写入单独的 ByHost 工作表，成功率按百分比格式显示
+func writeHostSheet(excel *excelize.File, summaries []HostSummary) error {
+	sheetName := "ByHost"
+	if _, err := excel.NewSheet(sheetName); err != nil {
+		return err
+	}
+	headers := []string{"主机", "URL 数", "成功", "失败", "成功率", "总字节数", "总大小"}
+	for j, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(j+1, 1)
+		excel.SetCellValue(sheetName, cell, h)
+	}
+
+	percent, err := excel.NewStyle(&excelize.Style{NumFmt: 10}) // 0.00%
+	if err != nil {
+		return err
+	}
+	if err := excel.SetColStyle(sheetName, "E", percent); err != nil {
+		return err
+	}
+
+	for i, s := range summaries {
+		row := i + 2
+		excel.SetCellValue(sheetName, fmt.Sprintf("A%d", row), s.Host)
+		excel.SetCellValue(sheetName, fmt.Sprintf("B%d", row), s.Count)
+		excel.SetCellValue(sheetName, fmt.Sprintf("C%d", row), s.Succeeded)
+		excel.SetCellValue(sheetName, fmt.Sprintf("D%d", row), s.Failed)
+		excel.SetCellValue(sheetName, fmt.Sprintf("E%d", row), s.SuccessRate)
+		excel.SetCellValue(sheetName, fmt.Sprintf("F%d", row), s.TotalBytes)
+		excel.SetCellValue(sheetName, fmt.Sprintf("G%d", row), formatFileSize(s.TotalBytes))
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"time"
+)
+
+// htmlReportTemplate 独立的 HTML 报告模板，点击表头可排序
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>URL 文件大小检查报告</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "PingFang SC", sans-serif; margin: 24px; color: #222; }
+.summary span { display: inline-block; margin-right: 24px; }
+table { border-collapse: collapse; width: 100%; margin-top: 16px; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; word-break: break-all; }
+th { background: #f3f4f6; cursor: pointer; user-select: none; }
+th.asc::after { content: " ▲"; }
+th.desc::after { content: " ▼"; }
+tr.failed td { background: #fdecea; color: #b71c1c; }
+</style>
+</head>
+<body>
+<h2>URL 文件大小检查报告</h2>
+<div class="summary">
+<span>生成时间：{{.Generated}}</span>
+<span>URL 总数：{{.Total}}</span>
+<span>成功：{{.Succeeded}}</span>
+<span>失败：{{.Failed}}</span>
+<span>总大小：{{.TotalSize}}</span>
+</div>
+<table id="results">
+<thead><tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr{{if .Failed}} class="failed"{{end}}>{{range .Cells}}<td data-v="{{.Key}}">{{.Text}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+<script>
+document.querySelectorAll("#results th").forEach(function (th, col) {
+  th.addEventListener("click", function () {
+    var asc = !th.classList.contains("asc");
+    document.querySelectorAll("#results th").forEach(function (h) { h.classList.remove("asc", "desc"); });
+    th.classList.add(asc ? "asc" : "desc");
+    var tbody = document.querySelector("#results tbody");
+    var rows = Array.prototype.slice.call(tbody.rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[col].dataset.v, y = b.cells[col].dataset.v;
+      var nx = parseFloat(x), ny = parseFloat(y);
+      var d = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+      return asc ? d : -d;
+    });
+    rows.forEach(function (r) { tbody.appendChild(r); });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// htmlCell HTML 报告中的单元格，Key 为排序依据
+type htmlCell struct {
+	Text string
+	Key  string
+}
+
+// htmlRow HTML 报告中的一行
+type htmlRow struct {
+	Failed bool
+	Cells  []htmlCell
+}
+
+// htmlReport HTML 报告模板的数据
+type htmlReport struct {
+	Generated string
+	Total     int
+	Succeeded int
+	Failed    int
+	TotalSize string
+	Headers   []string
+	Rows      []htmlRow
+}
+
+// writeToHTML 将结果写入独立的 HTML 报告，失败的行标红显示，生成时间按 TimeFormat 格式化
+func writeToHTML(results []Result, columns []column, outputPath string, opts Options) error {
+	printer := newNumberPrinter(opts)
+	report := htmlReport{
+		Generated: formatTimeLayout(time.Now(), opts.TimeFormat),
+		Total:     len(results),
+	}
+	for _, col := range columns {
+		report.Headers = append(report.Headers, col.header)
+	}
+
+	var totalBytes int64
+	for _, r := range results {
+		if r.Failed() {
+			report.Failed++
+		} else {
+			report.Succeeded++
+			totalBytes += r.Bytes
+		}
+
+		row := htmlRow{Failed: r.Failed()}
+		for _, col := range columns {
+			text := cellText(col, r, printer)
+			key := fmt.Sprint(col.value(r))
+			if col.sortKey != nil {
+				key = fmt.Sprint(col.sortKey(r))
+			}
+			row.Cells = append(row.Cells, htmlCell{Text: text, Key: key})
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	report.TotalSize = formatFileSize(totalBytes)
+
+	file, err := createOutput(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := htmlReportTemplate.Execute(file, report); err != nil {
+		return fmt.Errorf("生成 HTML 报告失败: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTMLGeneratedTimeFormat(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report.html")
+	opts := Options{TimeFormat: "2006/01/02"}.withDefaults()
+	results := []Result{{URL: "http://example.com/a", Size: "1.00 KB", Bytes: 1024}}
+	if err := writeOutput(results, computeStats(results, 0), nil, nil, out, opts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "生成时间：" + time.Now().Format("2006/01/02"); !strings.Contains(string(data), want) {
+		t.Fatalf("报告中没有 %q", want)
+	}
+}
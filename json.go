@@ -0,0 +1,33 @@
+package main
+
+import "encoding/json"
+
+// jsonVersion JSON 输出格式的版本，结果的结构发生不兼容变化时加一
+const jsonVersion = 1
+
+// jsonOutput JSON 输出的外层结构
+type jsonOutput struct {
+	Version int      `json:"version"`
+	Stats   Stats    `json:"stats"`
+	Results []Result `json:"results"`
+}
+
+// writeToJSON 将完整的结果（包含全部字段）连同版本和统计写入 JSON 文件
+func writeToJSON(results []Result, stats Stats, outputPath string) error {
+	if results == nil {
+		results = []Result{}
+	}
+	data, err := json.MarshalIndent(jsonOutput{Version: jsonVersion, Stats: stats, Results: results}, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := createOutput(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// targetScanner 依次将目标交给 fn，fn 返回错误时停止并返回该错误
+type targetScanner func(fn func(Target) error) error
+
+// sliceScanner 将内存中的目标列表包装为 targetScanner
+func sliceScanner(targets []Target) targetScanner {
+	return func(fn func(Target) error) error {
+		for _, t := range targets {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// shardScanner 只把分到本分片的目标交给 fn
+func shardScanner(scan targetScanner, opts Options) targetScanner {
+	return func(fn func(Target) error) error {
+		return scan(func(t Target) error {
+			if !inShard(t.URL, opts) {
+				return nil
+			}
+			return fn(t)
+		})
+	}
+}
+
+// errStopScan 检查被取消后用于停止读取输入
+var errStopScan = errors.New("停止读取输入")
+
+// checkLowMemory 低内存模式：边读输入边检查，每 BatchSize 个目标检查完就写入输出并丢弃，
+// 内存占用与输入规模无关。代价是输出不排序，按输入顺序逐批写入，且不支持需要全部结果的
+// duplicateCheck、typeSummary、hostSummary、runInfoSheet、sizeChart、resume 和 Excel 模板、超链接。scan 会被调用两次，第一次只统计总数用于进度显示。
+// 取消时已写入的批次保留在输出文件中，其余目标不再写入。完成后统计可通过 LastStats 获取
+func (a *App) checkLowMemory(parent context.Context, scan targetScanner, opts Options) (err error) {
+	start := time.Now()
+	var outputPath string
+	var stats Stats
+	defer func() {
+		if err != nil {
+			a.emit("error", ErrorEvent{Kind: errorKind(err), Message: err.Error(), Stats: stats})
+		}
+		a.notifyWebhook(opts, outputPath, stats, err)
+	}()
+
+	if opts, err = a.prepareOptions(opts); err != nil {
+		return err
+	}
+	if opts.DuplicateCheck || opts.SameFinalCheck || opts.TypeSummary || opts.HostSummary || opts.RunInfoSheet || opts.SizeChart || opts.Resume ||
+		opts.FlushEvery > 0 || opts.FlushInterval > 0 || opts.TopN > 0 {
+		return fmt.Errorf("%w: 低内存模式不支持 duplicateCheck、sameFinalCheck、typeSummary、hostSummary、runInfoSheet、sizeChart、resume、flushEvery、flushInterval 和 topN", ErrInvalidOptions)
+	}
+	// 流式写入的 Excel 只能从 A1 开始逐行写入新文件
+	if opts.ExcelTemplate != "" || opts.ExcelHyperlinks || opts.ExcelStartRow > 1 || opts.ExcelStartCol > 1 {
+		return fmt.Errorf("%w: 低内存模式不支持 excelTemplate、excelHyperlinks、excelStartRow 和 excelStartCol", ErrInvalidOptions)
+	}
+
+	if opts.ShardCount > 1 {
+		scan = shardScanner(scan, opts)
+	}
+
+	total := 0
+	if err := scan(func(Target) error { total++; return nil }); err != nil {
+		return err
+	}
+	if total == 0 {
+		return ErrNoURLs
+	}
+
+	if opts.OutputFile != "" {
+		if outputPath, err = resolveOutputPath(opts.OutputFile); err != nil {
+			return err
+		}
+	}
+
+	var cp *checkpoint
+	if opts.CheckpointFile != "" {
+		checkpointPath, err := resolveOutputPath(opts.CheckpointFile)
+		if err != nil {
+			return err
+		}
+		if cp, err = openCheckpoint(checkpointPath, false); err != nil {
+			return err
+		}
+		defer cp.Close()
+	}
+
+	c, err := newChecker(opts)
+	if err != nil {
+		return err
+	}
+	if opts.MetricsAddr != "" {
+		if c.metrics, err = a.startMetrics(opts.MetricsAddr); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel, run := a.startRun(parent, total)
+	defer cancel()
+
+	w, err := newResultWriter(outputPath, opts)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteOutput, err)
+	}
+	defer func() {
+		// 正常结束时已在下面关闭，这里只处理提前返回的情况
+		if w != nil {
+			w.Close()
+		}
+	}()
+
+	batch := make([]Target, 0, opts.BatchSize)
+	flush := func() error {
+		results := a.runTargets(ctx, run, c, cp, nil, batch, total)
+		batch = batch[:0]
+		stats.add(results)
+		if err := w.write(filterResults(results, opts.OutputFilter)); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteOutput, err)
+		}
+		if ctx.Err() != nil {
+			return errStopScan
+		}
+		return nil
+	}
+
+	err = scan(func(t Target) error {
+		batch = append(batch, t)
+		if len(batch) < opts.BatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if err != nil && !errors.Is(err, errStopScan) {
+		return err
+	}
+	closeErr := w.Close()
+	w = nil
+	if closeErr != nil {
+		return fmt.Errorf("%w: %w", ErrWriteOutput, closeErr)
+	}
+
+	stats.Elapsed = Duration(time.Since(start))
+	stats.StartedAt = start
+	stats.EstimatedDownload = estimateDownload(stats.TotalBytes, opts.AssumedBandwidth)
+	stats.Requests = c.requests.Load()
+	stats.BytesRead = c.bodyBytes.Load()
+	stats.Retries = c.retries.Load()
+	stats.TrippedHosts = c.breaker.trippedHosts()
+	a.mu.Lock()
+	a.stats = stats
+	a.mu.Unlock()
+
+	if cancelErr := ctx.Err(); cancelErr != nil {
+		return fmt.Errorf("%w: %w", ErrCancelled, cancelErr)
+	}
+	if cp != nil {
+		if err := cp.Close(); err != nil {
+			return fmt.Errorf("%w: %w", ErrWriteOutput, err)
+		}
+	}
+
+	a.emit("done", DoneEvent{OutputPath: outputPath, Stats: stats})
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLowMemoryRejectsExcelLayoutOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"excelTemplate", Options{ExcelTemplate: "template.xlsx"}},
+		{"excelHyperlinks", Options{ExcelHyperlinks: true}},
+		{"excelStartRow", Options{ExcelStartRow: 5}},
+		{"excelStartCol", Options{ExcelStartCol: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.LowMemory = true
+			a := NewApp()
+			a.SetOptions(opts)
+			_, err := a.CheckFileSizeConcurrent([]string{"http://127.0.0.1:1/a"}, 1, filepath.Join(t.TempDir(), "out.xlsx"))
+			if !errors.Is(err, ErrInvalidOptions) {
+				t.Fatalf("err = %v，应为 ErrInvalidOptions", err)
+			}
+		})
+	}
+}
+
+func TestExcelStreamWriterCloseRemovesTempFiles(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	// 保存到不存在的目录会失败
+	ew, err := newExcelStreamWriter(filepath.Join(tmp, "missing", "out.xlsx"), outputColumns(Options{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 行数据超过 excelize 的 16MB 内存块后写入临时文件
+	long := "http://example.com/" + strings.Repeat("a", 4096)
+	results := make([]Result, 5000)
+	for i := range results {
+		results[i] = Result{URL: long, Bytes: int64(i)}
+	}
+	if err := ew.write(results); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(tmp); len(entries) == 0 {
+		t.Fatal("未生成 excelize 临时文件，测试数据太少")
+	}
+	if err := ew.Close(); err == nil {
+		t.Fatal("保存到不存在的目录应返回错误")
+	}
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		t.Errorf("保存失败后残留临时文件 %s", e.Name())
+	}
+}
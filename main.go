@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -11,7 +12,15 @@ import (
 //go:embed all:frontend/dist
 var assets embed.FS
 
+// version 程序版本，发布时通过 -ldflags "-X main.version=v1.2.3" 设置
+var version = "dev"
+
 func main() {
+	// 带参数启动时以命令行模式运行，不创建窗口
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// MergeResults 合并多个已保存的结果文件，同一 URL（按规范形式比较）保留检查时间最新的一条，写入 outputFile
+func (a *App) MergeResults(inputs []string, outputFile string) ([]Result, error) {
+	opts := a.options()
+	if outputFile != "" {
+		opts.OutputFile = outputFile
+	}
+	return mergeResultFiles(inputs, opts.withDefaults())
+}
+
+// mergeResultFiles 读取并合并结果文件，按文件大小排序后写入 opts.OutputFile。
+// 检查时间相同（如文件中没有检查时间列）时，后面的文件优先
+func mergeResultFiles(inputs []string, opts Options) ([]Result, error) {
+	if len(inputs) == 0 {
+		return nil, errors.New("没有要合并的结果文件")
+	}
+
+	outputPath, err := resolveOutputPath(opts.OutputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var merged []Result
+	for _, path := range inputs {
+		results, err := readResults(path, opts.TimeFormat)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+		}
+		for _, r := range results {
+			key := canonicalURL(r.URL, opts.CanonicalSortQuery)
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(merged)
+				merged = append(merged, r)
+				continue
+			}
+			if !r.CheckedAt.Before(merged[i].CheckedAt) {
+				merged[i] = r
+			}
+		}
+	}
+
+	if !opts.SkipSort {
+		sortResults(merged, opts.SortBy, opts.SortOrder)
+	}
+	if err := writeOutput(merged, computeStats(merged, 0), nil, nil, outputPath, opts); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// readResults 按扩展名读取已保存的结果文件，文本格式的文件可以是 .gz 压缩的。
+// timeLayout 为写入时使用的 TimeFormat，用于解析表格中的检查时间列
+func readResults(path, timeLayout string) ([]Result, error) {
+	if err := checkGzipFormat(path); err != nil {
+		return nil, err
+	}
+	switch outputFormat(path) {
+	case ".json":
+		return readFromJSON(path)
+	case ".db", ".sqlite":
+		return readFromSQLite(path)
+	case ".csv":
+		return readFromCSV(path, ',', timeLayout)
+	case ".tsv":
+		return readFromCSV(path, '\t', timeLayout)
+	case ".xlsx":
+		return readFromExcel(path, timeLayout)
+	default:
+		return nil, fmt.Errorf("不支持读取的结果文件格式: %s", outputFormat(path))
+	}
+}
+
+// readFromJSON 读取 JSON 结果文件
+func readFromJSON(path string) ([]Result, error) {
+	file, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	// 兼容没有外层信封、直接是结果数组的旧版文件
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var results []Result
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
+
+	var out jsonOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	if out.Version > jsonVersion {
+		return nil, fmt.Errorf("不支持的 JSON 结果版本: %d", out.Version)
+	}
+	return out.Results, nil
+}
+
+// readFromSQLite 读取 SQLite 结果库中的全部记录
+func readFromSQLite(path string) ([]Result, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT url, size, bytes, status_code, content_type, final_url, error, checked_at
+		FROM results ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var r Result
+		var checkedAt string
+		if err := rows.Scan(&r.URL, &r.Size, &r.Bytes, &r.StatusCode, &r.ContentType, &r.FinalURL, &r.Err, &checkedAt); err != nil {
+			return nil, err
+		}
+		r.CheckedAt, _ = time.Parse(time.RFC3339Nano, checkedAt)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// readFromCSV 读取 CSV/TSV 结果文件，comma 为分隔符，timeLayout 同 parseRows
+func readFromCSV(path string, comma rune, timeLayout string) ([]Result, error) {
+	file, err := openInput(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// 跳过开头可能有的 UTF-8 BOM
+	br := bufio.NewReader(file)
+	if b, err := br.Peek(len(utf8BOM)); err == nil && string(b) == utf8BOM {
+		br.Discard(len(utf8BOM))
+	}
+	reader := csv.NewReader(br)
+	reader.Comma = comma
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return parseRows(records, 1, timeLayout)
+}
+
+// readFromExcel 读取 Excel 结果文件：优先读取 Results 工作表，没有时读取当前工作表（如按模板写入的文件）。
+// 表头不必从 A1 开始，取第一个含“URL”单元格的行为表头，从该单元格所在的列开始读取。timeLayout 同 parseRows
+func readFromExcel(path, timeLayout string) ([]Result, error) {
+	excel, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer excel.Close()
+
+	sheet := "Results"
+	if index, _ := excel.GetSheetIndex(sheet); index < 0 {
+		sheet = excel.GetSheetName(excel.GetActiveSheetIndex())
+	}
+	rows, err := excel.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	headerRow, startCol := findHeader(rows)
+	if headerRow < 0 {
+		return nil, fmt.Errorf("工作表 %s 中找不到 URL 列的表头", sheet)
+	}
+	table := make([][]string, 0, len(rows)-headerRow)
+	for _, row := range rows[headerRow:] {
+		if len(row) > startCol {
+			row = row[startCol:]
+		} else {
+			row = nil
+		}
+		table = append(table, row)
+	}
+	return parseRows(table, headerRow+1, timeLayout)
+}
+
+// findHeader 返回第一个值为“URL”的单元格所在的行和列（从 0 开始），找不到时都为 -1
+func findHeader(rows [][]string) (int, int) {
+	for i, row := range rows {
+		for j, cell := range row {
+			if cell == "URL" {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// parseRows 按表头将表格行解析为结果，未知的列和空行会被忽略。headerLine 为表头在文件中的行号，用于错误信息；
+// timeLayout 不为空时检查时间列先按它解析，失败时再按 RFC3339 解析
+func parseRows(rows [][]string, headerLine int, timeLayout string) ([]Result, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	columns := make([]*column, len(rows[0]))
+	hasURL := false
+	for j, header := range rows[0] {
+		if col, ok := columnByHeader(header); ok {
+			if header == "检查时间" && timeLayout != "" {
+				col.parse = func(r *Result, s string) error { return parseCheckedAt(r, s, timeLayout) }
+			}
+			columns[j] = &col
+			hasURL = hasURL || header == "URL"
+		}
+	}
+	if !hasURL {
+		return nil, errors.New("缺少 URL 列")
+	}
+
+	results := make([]Result, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if len(row) == 0 {
+			continue
+		}
+		var r Result
+		for j, cell := range row {
+			if j >= len(columns) || columns[j] == nil {
+				continue
+			}
+			if err := columns[j].parse(&r, cell); err != nil {
+				return nil, fmt.Errorf("第 %d 行 %s 列解析失败: %w", headerLine+i+1, columns[j].header, err)
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadFromExcelTemplateOffset(t *testing.T) {
+	dir := t.TempDir()
+	template := filepath.Join(dir, "template.xlsx")
+	tf := excelize.NewFile()
+	tf.SetCellStr("Sheet1", "A1", "文件大小报告")
+	if err := tf.SaveAs(template); err != nil {
+		t.Fatal(err)
+	}
+	tf.Close()
+
+	opts := Options{ExcelTemplate: template, ExcelStartRow: 4, ExcelStartCol: 2, BytesColumn: true}.withDefaults()
+	results := []Result{
+		{URL: "http://example.com/a", Size: formatFileSize(2048), Bytes: 2048},
+		{URL: "http://example.com/b", Size: formatFileSize(10), Bytes: 10},
+	}
+	out := filepath.Join(dir, "out.xlsx")
+	if err := writeToExcel(results, outputColumns(opts), Stats{}, nil, nil, out, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readFromExcel(out, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(results) {
+		t.Fatalf("读回 %d 条结果，应为 %d 条", len(got), len(results))
+	}
+	for i, r := range got {
+		if r.URL != results[i].URL || r.Bytes != results[i].Bytes || r.Size != results[i].Size {
+			t.Errorf("第 %d 条 = %+v，应为 %+v", i, r, results[i])
+		}
+	}
+
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if title, _ := f.GetCellValue("Sheet1", "A1"); title != "文件大小报告" {
+		t.Errorf("模板内容被覆盖: A1 = %q", title)
+	}
+}
+
+func TestReadFromExcelWithoutHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "other.xlsx")
+	f := excelize.NewFile()
+	f.SetCellStr("Sheet1", "A1", "标题")
+	f.SetCellStr("Sheet1", "A2", "http://example.com/a")
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := readFromExcel(path, ""); err == nil {
+		t.Fatal("没有 URL 表头的文件应报错")
+	}
+}
+
+func TestParseRowsTimeLayout(t *testing.T) {
+	const layout = "2006-01-02 15:04:05"
+	rows := [][]string{
+		{"URL", "检查时间"},
+		{"http://example.com/a", "2026-10-14 08:30:00"},
+		{"http://example.com/b", "2026-10-14T08:30:00Z"}, // 用默认格式写入的文件
+	}
+	results, err := parseRows(rows, 1, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 14, 8, 30, 0, 0, time.Local); !results[0].CheckedAt.Equal(want) {
+		t.Errorf("按 TimeFormat 解析 = %v，应为 %v", results[0].CheckedAt, want)
+	}
+	if want := time.Date(2026, 10, 14, 8, 30, 0, 0, time.UTC); !results[1].CheckedAt.Equal(want) {
+		t.Errorf("按 RFC3339 解析 = %v，应为 %v", results[1].CheckedAt, want)
+	}
+
+	if _, err := parseRows(rows, 1, ""); err == nil {
+		t.Error("未设置 TimeFormat 时自定义格式的时间应解析失败")
+	}
+}
+
+func TestGroupDigitsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	for _, locale := range []string{"en", "de"} {
+		opts := Options{GroupDigits: true, Locale: locale, IndexColumn: true, BytesColumn: true}.withDefaults()
+		results := []Result{
+			{URL: "http://example.com/a", Size: formatFileSize(1572864000), Bytes: 1572864000, Index: 1500},
+			{URL: "http://example.com/b", Size: formatFileSize(2048), Bytes: 2048, Index: 12345},
+		}
+		in := filepath.Join(dir, locale+".csv")
+		if err := writeOutput(results, computeStats(results, 0), nil, nil, in, opts); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 只有字节数列加千位分隔符
+		text := string(data)
+		if grouped := map[string]string{"en": "1,572,864,000", "de": "1.572.864.000"}[locale]; !strings.Contains(text, grouped) {
+			t.Errorf("%s: 字节数没有分组:\n%s", locale, text)
+		}
+		if !strings.Contains(text, "\n1500,") || !strings.Contains(text, "\n12345,") {
+			t.Errorf("%s: 输入位置不应分组:\n%s", locale, text)
+		}
+
+		opts.OutputFile = filepath.Join(dir, locale+"-merged.csv")
+		merged, err := mergeResultFiles([]string{in}, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", locale, err)
+		}
+		for i, r := range merged {
+			if r.URL != results[i].URL || r.Bytes != results[i].Bytes || r.Index != results[i].Index {
+				t.Errorf("%s: 第 %d 条读回为 %+v，应为 %+v", locale, i, r, results[i])
+			}
+		}
+	}
+}
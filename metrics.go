@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics 检查过程的 Prometheus 指标，只在设置了 MetricsAddr 时创建。
+// 方法允许在 nil 上调用，未开启指标时不做任何事
+type metrics struct {
+	requests  prometheus.Counter
+	failures  *prometheus.CounterVec
+	retries   prometheus.Counter
+	duration  prometheus.Histogram
+	fileBytes prometheus.Histogram
+}
+
+// newMetrics 创建指标并注册到 reg
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		requests: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "urlfilesize_requests_total",
+			Help: "发出的 HTTP 请求数，含重试和回退请求",
+		}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "urlfilesize_failures_total",
+			Help: "重试后仍失败的 URL 数，按失败类型区分",
+		}, []string{"kind"}),
+		retries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "urlfilesize_retries_total",
+			Help: "重试次数",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "urlfilesize_request_duration_seconds",
+			Help:    "单个 HTTP 请求的耗时，含读取大小",
+			Buckets: prometheus.DefBuckets,
+		}),
+		fileBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "urlfilesize_file_size_bytes",
+			Help:    "检查成功的文件大小",
+			Buckets: prometheus.ExponentialBuckets(1024, 4, 12), // 1 KB 到 4 TB
+		}),
+	}
+	reg.MustRegister(m.requests, m.failures, m.retries, m.duration, m.fileBytes)
+	return m
+}
+
+// observeRequest 记录一个 HTTP 请求及其耗时
+func (m *metrics) observeRequest(elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.requests.Inc()
+	m.duration.Observe(elapsed.Seconds())
+}
+
+// observeRetry 记录一次重试
+func (m *metrics) observeRetry() {
+	if m == nil {
+		return
+	}
+	m.retries.Inc()
+}
+
+// observeResult 记录一个 URL 的最终结果
+func (m *metrics) observeResult(r Result, err error) {
+	if m == nil {
+		return
+	}
+	if err != nil {
+		m.failures.WithLabelValues(failureKind(err)).Inc()
+		return
+	}
+	m.fileBytes.Observe(float64(r.Bytes))
+}
+
+// failureKind 将错误归类为指标中的失败类型
+func failureKind(err error) string {
+	var se *statusError
+	var de *net.DNSError
+	var ne net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, errWatchdog):
+		return "timeout"
+	case errors.As(err, &se):
+		return "status"
+	case errors.Is(err, errHostTripped):
+		return "tripped"
+	case errors.Is(err, errTooManyOpenFiles):
+		return "too_many_open_files"
+	case errors.Is(err, errUnknownSize):
+		return "unknown_size"
+	case errors.As(err, &de):
+		return "dns"
+	case errors.As(err, &ne):
+		if ne.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	default:
+		return "other"
+	}
+}
+
+// serveMetrics 在 addr 上提供 /metrics，监听失败时立即返回错误
+func serveMetrics(addr string, reg *prometheus.Registry) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("监听指标地址 %s 失败: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	return srv, nil
+}
+
+// startMetrics 首次需要时创建指标并启动 HTTP 服务，此后各次检查共用同一组指标，
+// 计数在整个进程内累计，便于定时运行时持续抓取。MetricsAddr 改变时在新地址上重新提供服务，
+// 新地址监听成功后才关闭旧服务
+func (a *App) startMetrics(addr string) (*metrics, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.metrics != nil && a.metricsAddr == addr {
+		return a.metrics, nil
+	}
+
+	if a.metrics == nil {
+		a.metricsReg = prometheus.NewRegistry()
+		a.metrics = newMetrics(a.metricsReg)
+	}
+	srv, err := serveMetrics(addr, a.metricsReg)
+	if err != nil {
+		return nil, err
+	}
+	if a.metricsServer != nil {
+		a.metricsServer.Close()
+	}
+	a.metricsServer, a.metricsAddr = srv, addr
+	return a.metrics, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// freeAddr 返回一个当前空闲的本地地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// scrapeMetric 从 addr 的 /metrics 读取指标 name 的值
+func scrapeMetric(t *testing.T, addr, name string) string {
+	t.Helper()
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		if value, ok := strings.CutPrefix(sc.Text(), name+" "); ok {
+			return value
+		}
+	}
+	t.Fatalf("%s 中没有指标 %s", addr, name)
+	return ""
+}
+
+func TestMetricsCountHTTPRequests(t *testing.T) {
+	srv := newSizeServer(t)
+	a := NewApp()
+	addr := freeAddr(t)
+	// /size/0 先发 HEAD 再改发 GET，一个 URL 两个请求
+	a.SetOptions(Options{MetricsAddr: addr, GetOnEmptyHead: true})
+	if _, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/size/0", srv.URL + "/size/5"}, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+	if n := a.LastStats().Requests; n != 3 {
+		t.Fatalf("Requests = %d，应为 3", n)
+	}
+	if got := scrapeMetric(t, addr, "urlfilesize_requests_total"); got != "3" {
+		t.Fatalf("urlfilesize_requests_total = %s，应为 3", got)
+	}
+}
+
+func TestMetricsAddrChange(t *testing.T) {
+	srv := newSizeServer(t)
+	a := NewApp()
+	first, second := freeAddr(t), freeAddr(t)
+	for _, addr := range []string{first, second} {
+		a.SetOptions(Options{MetricsAddr: addr})
+		if _, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/size/5"}, 1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 计数跨检查累计，并在新地址上提供
+	if got := scrapeMetric(t, second, "urlfilesize_requests_total"); got != "2" {
+		t.Fatalf("urlfilesize_requests_total = %s，应为 2", got)
+	}
+	if resp, err := http.Get("http://" + first + "/metrics"); err == nil {
+		resp.Body.Close()
+		t.Fatalf("旧地址 %s 仍在提供指标", first)
+	}
+}
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// 选项的默认值
+const (
+	defaultConcurrency    = 10
+	defaultMaxConcurrency = 512
+	defaultTimeout        = 10 * time.Second
+	defaultDNSCacheTTL    = 5 * time.Minute
+
+	defaultConnectTimeout = 30 * time.Second
+
+	defaultBatchSize = 1000
+
+	defaultMaxIdleConns    = 100
+	reservedOpenFiles      = 32 // MaxOpenFiles 中为输出文件、标准输入输出等预留的文件描述符数
+	defaultIdleConnTimeout = 90 * time.Second
+
+	defaultSoftFailThreshold = 16 << 10 // 16 KB
+
+	defaultWebhookTimeout = 10 * time.Second
+
+	defaultBestEffortMaxBody = 100 << 20 // 100 MB
+	defaultSizePrecision     = 2
+)
+
+// Duration 时长，JSON 中可写成 "10s"、"1m30s" 或纳秒数
+type Duration time.Duration
+
+// MarshalJSON 将时长编码为 "10s" 形式的字符串
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON 解析字符串或数字形式的时长
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("无效的时长 %q: %w", s, err)
+		}
+		*d = Duration(v)
+		return nil
+	}
+
+	v, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的时长 %s", data)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// OutputFilter 输出过滤方式
+type OutputFilter string
+
+// 支持的输出过滤方式
+const (
+	FilterAll          OutputFilter = ""         // 写入全部结果
+	FilterSuccessOnly  OutputFilter = "success"  // 只写入成功的结果
+	FilterFailuresOnly OutputFilter = "failures" // 只写入失败的结果
+)
+
+// SizeStrategy 获取文件大小的方式
+type SizeStrategy string
+
+// 支持的取大小策略
+const (
+	StrategyHead    SizeStrategy = ""        // 默认：HEAD 请求读取 Content-Length
+	StrategyOptions SizeStrategy = "options" // OPTIONS 请求读取 SizeHeader 指定的响应头
+	StrategyGet     SizeStrategy = "get"     // GET 请求下载响应体并计数，适用于不返回 Content-Length 的服务器
+	StrategyAuto    SizeStrategy = "auto"    // 依次尝试 HEAD、Range GET（bytes=0-0）和完整 GET
+
+	// 以下只用于 Strategies 回退链
+	StrategyHeadStep  SizeStrategy = "head"  // HEAD 请求，同默认策略
+	StrategyRangeStep SizeStrategy = "range" // Range: bytes=0-0 的 GET，从 Content-Range 读取总大小
+)
+
+// SortColumn 结果的排序依据
+type SortColumn string
+
+// 支持的排序依据
+const (
+	SortBySize        SortColumn = ""            // 默认：文件大小
+	SortByURL         SortColumn = "url"         // URL 字母顺序
+	SortByStatus      SortColumn = "status"      // HTTP 状态码
+	SortByContentType SortColumn = "contentType" // Content-Type
+	SortByDuration    SortColumn = "duration"    // 检查耗时
+)
+
+// SortOrder 排序方向
+type SortOrder string
+
+// 支持的排序方向
+const (
+	SortDefault SortOrder = ""     // 按大小排序时倒序，其他为正序
+	SortAsc     SortOrder = "asc"  // 正序
+	SortDesc    SortOrder = "desc" // 倒序
+)
+
+// Options 检查选项，可通过 JSON 配置文件加载
+type Options struct {
+	Concurrency int               `json:"concurrency"` // 并发数，为 0 时使用默认值，超过 MaxConcurrency 时按上限处理
+	Timeout     Duration          `json:"timeout"`     // 单个请求的超时时间，为 0 时使用默认值
+	Headers     map[string]string `json:"headers"`     // 附加到每个请求的请求头
+	Proxy       string            `json:"proxy"`       // 代理地址，如 http://127.0.0.1:7890
+	OutputFile  string            `json:"outputFile"`  // 输出文件，相对路径相对于桌面目录，为空时不写入文件
+	Retries     int               `json:"retries"`     // 失败后的重试次数，哪些失败会重试见 RetryStatusCodes
+	HostDelay   Duration          `json:"hostDelay"`   // 同一主机相邻两次请求的最小间隔，为 0 时不限制
+
+	// 每个请求发出前的随机等待，在 [JitterMin, JitterMax] 内均匀取值，对所有主机生效，
+	// 与 HostDelay 叠加。JitterMax 为 0 时不等待
+	JitterMin Duration `json:"jitterMin"`
+	JitterMax Duration `json:"jitterMax"`
+
+	// 请求的 Accept 头，优先于 Headers 中的同名设置。内容协商的接口会按它返回不同的表示，
+	// 大小也随之不同，如分别指定 application/json 和 text/html 对比
+	Accept string `json:"accept"`
+
+	// 轮换使用的 User-Agent 列表，每个请求依次取下一个，为空时使用 Headers 中的 User-Agent 或默认值。
+	// 只能避开简单的按 User-Agent 拦截，无法绕过正规的反爬虫系统
+	UserAgents []string `json:"userAgents"`
+
+	// 主机过滤：设置了 AllowHosts 时只访问匹配其中之一的主机，匹配 DenyHosts 的主机一律不访问，
+	// 重定向的目标也受此限制。被过滤的 URL 不发请求，文件大小记为“主机被过滤”。
+	// 模式支持 * 等通配符，如 *.example.com，不区分大小写，不含端口
+	AllowHosts []string `json:"allowHosts"`
+	DenyHosts  []string `json:"denyHosts"`
+
+	// 设置后每个 HTTP 请求都带上 Authorization: Bearer <令牌>，只在同一主机内的重定向中保留
+	BearerToken string `json:"bearerToken"`
+
+	// 回源验证：连接 URL 中的地址（如源站 IP），但发送 HostHeader 指定的 Host 请求头，
+	// TLS 握手时使用 TLSServerName 作为 SNI 并按它校验证书，为空时取 HostHeader 中的主机名。
+	// 相对路径的重定向保留 HostHeader，跳到其他主机的重定向使用新地址的 Host；
+	// TLSServerName 对所有连接生效，重定向到其他 HTTPS 主机时通常会因证书不符而失败，
+	// 需要时可将重定向状态码加入 AcceptStatusCodes 不再跟随
+	HostHeader    string `json:"hostHeader"`
+	TLSServerName string `json:"tlsServerName"`
+
+	// 看门狗：单个 URL 的检查（含重试）超过该时长时强制记为“超时(看门狗)”，为 0 时不启用。
+	// 应大于 Timeout 与重试耗时之和，只用于兜底卡住的请求
+	WatchdogTimeout Duration `json:"watchdogTimeout"`
+
+	// 按主机熔断：同一主机连续 BreakerThreshold 次故障（网络错误、超时、5xx、429，重试后仍失败才算一次）后，
+	// 该主机其余的 URL 不再请求，文件大小记为“主机熔断”，为 0 时不启用。BreakerCooldown 不为 0 时，
+	// 熔断超过这么久后放行一个 URL 试探，成功则恢复。熔断过的主机记录在统计中
+	BreakerThreshold int      `json:"breakerThreshold"`
+	BreakerCooldown  Duration `json:"breakerCooldown"`
+
+	// 建立连接的超时时间，为 0 时使用默认值 30s。设得比 Timeout 短可以让不可达的主机
+	// 尽快失败，同时允许可达的慢服务器使用完整的 Timeout
+	ConnectTimeout Duration `json:"connectTimeout"`
+
+	// 变量展开：开启后检查前将 URL 中的 ${NAME} 替换为 EnvVars 或环境变量中的值，$$ 表示字面的 $。
+	// 替换的值不做百分号编码。StrictEnv 为 true 时未定义的变量使该 URL 失败，否则替换为空。
+	// 结果中的 URL 仍是展开前的原文
+	ExpandEnv bool              `json:"expandEnv"`
+	StrictEnv bool              `json:"strictEnv"`
+	EnvVars   map[string]string `json:"envVars"`
+
+	// 整次检查共用的重试次数上限，为 0 时不限制。用完后其余失败直接记录，不再重试，
+	// 避免服务器出问题时重试放大成大量额外请求
+	RetryBudget int `json:"retryBudget"`
+	// 会触发重试的状态码，未设置时为 429、500、502、503、504；设为空数组则不按状态码重试
+	RetryStatusCodes []int `json:"retryStatusCodes"`
+	// 是否重试网络错误（连接失败、超时等），未设置时重试
+	RetryNetworkErrors *bool `json:"retryNetworkErrors"`
+
+	// 并发数上限，为 0 时使用默认值 512。并发数误填得过大会创建大量 goroutine 并耗尽文件描述符
+	MaxConcurrency int `json:"maxConcurrency"`
+
+	// 额外视为成功的状态码，如不跟随重定向时的 302。响应带 Content-Length 时使用它，
+	// 否则与没有 Content-Length 的 200 一样处理（auto 策略会继续尝试其他方式）。
+	// 其中的重定向状态码不会再被跟随
+	AcceptStatusCodes []int `json:"acceptStatusCodes"`
+
+	// 低内存模式：边读输入边检查，每检查完 BatchSize 个 URL 就写入输出文件并丢弃，适合数百万行的列表。
+	// 输出按输入顺序而不是按大小排序，只支持 CSV、TSV 和 Excel，不支持 duplicateCheck、typeSummary、
+	// hostSummary、resume、Excel 模板和超链接，检查函数也不再返回结果
+	LowMemory bool `json:"lowMemory"`
+	BatchSize int  `json:"batchSize"` // 低内存模式每批的 URL 数，为 0 时使用默认值 1000
+
+	// 分片：ShardCount 大于 1 时按 URL 的哈希把输入分成 ShardCount 份，只检查第 ShardIndex 份（从 0 开始）。
+	// 分配只取决于 URL 本身，各分片可以在不同机器上运行，最后用合并功能汇总
+	ShardIndex int `json:"shardIndex"`
+	ShardCount int `json:"shardCount"`
+
+	// 比较 URL 是否相同（合并结果、续跑时跳过已完成的 URL）时是否忽略查询参数的顺序。
+	// 比较时总是忽略主机名大小写、默认端口和主机名末尾的点
+	CanonicalSortQuery bool `json:"canonicalSortQuery"`
+
+	// 检查点：每完成一个 URL 向 CheckpointFile 追加一行 JSON。Resume 为 true 时
+	// 只检查检查点中没有的 URL，并与之前的结果合并后写入输出文件
+	CheckpointFile string `json:"checkpointFile"`
+	Resume         bool   `json:"resume"`
+	// 每完成 FlushEvery 个 URL 或每隔 FlushInterval 把已完成的结果写入输出文件，便于中途打开查看，
+	// 崩溃或中断时也留下可用的文件。两者都为 0 时只在结束时写入，低内存模式不支持；
+	// SQLite 输出会保留之前的记录，不写入中间结果，需要时用 CheckpointFile 记录进度
+	FlushEvery    int      `json:"flushEvery"`
+	FlushInterval Duration `json:"flushInterval"`
+
+	// 取大小策略，默认发送 HEAD 请求。少数只响应 OPTIONS 的接口可改用 options，
+	// 并通过 SizeHeader 指定携带大小的响应头；拒绝 HEAD 或不返回 Content-Length 的服务器可用 auto
+	SizeStrategy SizeStrategy `json:"sizeStrategy"`
+	SizeHeader   string       `json:"sizeHeader"`
+	// 自定义回退链：按顺序尝试其中的方式（head、options、range、get），得到大小即停止，
+	// 整个回退链共用一次请求超时，实际得到大小的方式记录在取大小方式列。设置后忽略 SizeStrategy
+	Strategies []SizeStrategy `json:"strategies"`
+	// 默认策略和 options 策略改用的请求方法，如对通过 POST 返回大小的接口设为 POST，为空时分别为 HEAD 和 OPTIONS。
+	// POST、PATCH 等非幂等方法的请求失败后默认不重试，以免重复提交产生副作用，确认接口可以重复调用时
+	// 将 RetryNonIdempotent 设为 true；HEAD、GET、OPTIONS 等不受影响
+	Method             string `json:"method"`
+	RetryNonIdempotent bool   `json:"retryNonIdempotent"`
+	// 默认策略下 HEAD 返回的 Content-Length 为 0 或缺失时，改发 GET 读取其 Content-Length（不下载响应体），
+	// 用于 HEAD 与 GET 返回不一致的服务器。得到大小的方式记录在取大小方式列，GET 失败时保留 HEAD 的结果
+	GetOnEmptyHead bool `json:"getOnEmptyHead"`
+	// 读取响应体的总字节数预算，为 0 时不限制。耗尽后其余 URL 不再下载响应体，
+	// 改用 HEAD 的 Content-Length 并在备注中标记“预算耗尽”，避免意外下载大量数据
+	MaxTotalBytes int64 `json:"maxTotalBytes"`
+	// 目标带预期大小（expectedSize）时允许的误差：绝对字节数或预期大小的百分比，满足其一即不算大小不符，
+	// 都为 0 时要求完全一致。ExpectedSizeColumns 为 true 时输出预期字节数、大小差值和大小不符列
+	SizeTolerance        int64   `json:"sizeTolerance"`
+	SizeTolerancePercent float64 `json:"sizeTolerancePercent"`
+	ExpectedSizeColumns  bool    `json:"expectedSizeColumns"`
+
+	// 每个 URL 检查的次数，大于 1 时用于发现大小不稳定（如 Content-Length 每次不同）的动态资源，
+	// 输出最小、最大、众数字节数和大小是否稳定。请求数随之成倍增加
+	Repeat int `json:"repeat"`
+	// 尽力取大小：HEAD 得不到大小（没有 Content-Length 或被拒绝）时自动依次改用 Range GET 和完整 GET，
+	// 即固定使用 auto 策略（设置了 Strategies 时仍按 Strategies），完整下载受 MaxBodyBytes 限制，
+	// 未设置时为 100 MB。实际得到大小的方式和是否达到上限分别记录在取大小方式和达到大小上限列
+	BestEffortSize bool `json:"bestEffortSize"`
+	// 下载响应体时所有请求合计的带宽上限（字节/秒），为 0 时不限速。只影响 get 策略和回退链中的完整 GET
+	MaxBandwidth int64 `json:"maxBandwidth"`
+	// 下载响应体时按大小占用并发槽：Content-Length 每满这么多字节多占一个槽，最多占满全部并发数，
+	// 这样大文件同时下载的数量更少，小文件仍可充分并发。大小未知时只占一个槽，为 0 时不按大小加权。
+	// 只影响 get 策略和回退链中的完整 GET，只发 HEAD 的检查始终每个 URL 占一个槽
+	SizeWeightBytes int64 `json:"sizeWeightBytes"`
+	// 假定的下载带宽（字节/秒），设置后按成功文件的总字节数估算全部下载所需的时间，
+	// 记录在统计和 RunInfo 工作表中，用于规划镜像同步。只用于估算，不影响检查
+	AssumedBandwidth int64 `json:"assumedBandwidth"`
+	// 单个响应体最多读取的字节数，为 0 时不限制。下载响应体取大小时超过该值即停止读取，
+	// 结果记为失败，文件大小为“超过限制”，字节数为已读取的部分
+	MaxBodyBytes int64 `json:"maxBodyBytes"`
+	// 下载响应体时是否统计压缩比：显式请求 gzip、deflate 或 br，分别记录传输字节数和解压后的字节数，
+	// 只对实际下载响应体的 get 策略和 auto 策略的完整 GET 有效
+	MeasureCompression bool `json:"measureCompression"`
+	// 整次检查最多发出的 HTTP 请求数（含重试和回退），为 0 时不限制。达到后不再派发新的 URL，
+	// 其余 URL 记为失败“已达到请求数上限”，用于控制按次计费接口的用量
+	MaxRequests int `json:"maxRequests"`
+
+	DNSCache    bool     `json:"dnsCache"`    // 是否启用进程内 DNS 缓存（默认关闭）
+	DNSCacheTTL Duration `json:"dnsCacheTTL"` // DNS 缓存有效期，为 0 时使用默认值
+
+	// 连接池调优，为 0 时使用默认值：MaxIdleConns 为 100，MaxIdleConnsPerHost 与并发数相同，
+	// IdleConnTimeout 为 90s
+	MaxIdleConns        int      `json:"maxIdleConns"`
+	MaxIdleConnsPerHost int      `json:"maxIdleConnsPerHost"`
+	IdleConnTimeout     Duration `json:"idleConnTimeout"`
+	DisableKeepAlives   bool     `json:"disableKeepAlives"` // 每个请求使用新连接
+	// 进程可用的文件描述符预算（如 ulimit -n 的值），为 0 时不限制。设置后扣除输出文件等预留的
+	// 32 个，再把并发数限制在余下的一半以内、空闲连接数限制在余下的部分，避免连接耗尽文件描述符
+	MaxOpenFiles int `json:"maxOpenFiles"`
+
+	// 强制使用 HTTP/1.1。个别服务器或 CDN 在 HTTP/2 下对 HEAD 请求处理异常，
+	// 例如不返回 Content-Length 或返回与 HTTP/1.1 不同的值，此时可关闭 HTTP/2 对比
+	DisableHTTP2 bool `json:"disableHTTP2"`
+
+	// 输出的列（表头）及其顺序，如 ["URL", "文件大小", "状态码", "内容类型"]，用于 Excel、CSV/TSV 和 HTML。
+	// 为空时输出 URL 和文件大小，再按其他选项添加相应的列；JSON 和 SQLite 始终包含全部字段
+	Columns []string `json:"columns"`
+
+	// 是否在 CSV/TSV 输出开头写入 UTF-8 BOM，用 Excel 直接打开时中文不会乱码；部分工具不认 BOM，默认关闭
+	CSVBOM bool `json:"csvBOM"`
+
+	// 写入前的排序依据和方向，失败的结果不论按哪一列排序都排在最后
+	SortBy    SortColumn `json:"sortBy"`
+	SortOrder SortOrder  `json:"sortOrder"`
+	// 跳过排序，只用于结果很多又不关心顺序时节省时间。输出不保证任何顺序，需要输入顺序时请输出输入位置列
+	SkipSort bool `json:"skipSort"`
+	// 只写入最大的 TopN 个文件（按字节数，失败的排在最后），为 0 时全部写入。所有 URL 仍会检查，
+	// 返回值和统计基于全部结果，写入的结果保持原有的排序。在 OutputFilter 之后生效，低内存模式不支持
+	TopN int `json:"topN"`
+
+	OutputFilter OutputFilter `json:"outputFilter"` // 输出过滤方式，只影响写入文件的结果
+	TypeSummary  bool         `json:"typeSummary"`  // 是否在 Excel 中附加按内容类型汇总的工作表
+	HostSummary  bool         `json:"hostSummary"`  // 是否在 Excel 中附加按主机汇总（含成功率）的工作表
+	RunInfoSheet bool         `json:"runInfoSheet"` // 是否在 Excel 中附加记录运行时间、版本、主要选项和统计的工作表
+	SizeChart    bool         `json:"sizeChart"`    // 是否在 Excel 中附加按大小区间统计文件数的柱状图工作表
+	BytesColumn  bool         `json:"bytesColumn"`  // 是否输出原始字节数列
+	IndexColumn  bool         `json:"indexColumn"`  // 是否在第一列输出 URL 在输入中的位置（从 0 开始）
+	ProtoColumn  bool         `json:"protoColumn"`  // 是否输出实际使用的 HTTP 协议版本列，可配合 DisableHTTP2 对比
+	// 是否输出实际提供响应的服务器 IP 列，用于按地域或机房审计
+	RemoteAddrColumn bool `json:"remoteAddrColumn"`
+	// 是否输出最终 URL 是否为明文 HTTP 的列，用于安全审计，统计中总是包含明文 HTTP 的 URL 数
+	InsecureColumn bool `json:"insecureColumn"`
+	// 是否输出重定向相关的列（最终 URL、是否跨域名重定向、重定向次数），用于安全审计
+	RedirectColumns bool `json:"redirectColumns"`
+	// 是否在结果中记录全部响应头，默认关闭以节省内存。只出现在 JSON 输出和返回值中，不输出到表格
+	CaptureHeaders bool `json:"captureHeaders"`
+	// Range 验证：检查成功后再发送 Range: bytes=0-0 的 GET，确认服务器真正支持断点续传，
+	// 并输出是否声明了 Accept-Ranges 和 Range 是否可用，用于发现声明支持但实际忽略 Range 的服务器
+	RangeCheck bool `json:"rangeCheck"`
+	// 检查完成后将大小和 ETag 都相同的结果标记为疑似重复（同一文件的不同镜像），并输出 ETag、重复组列
+	DuplicateCheck bool `json:"duplicateCheck"`
+	// 检查完成后将重定向到同一最终 URL 的不同输入 URL 编为一组，并输出最终 URL、同一最终URL组列，
+	// 用于清理只在重定向前不同的冗余条目
+	SameFinalCheck bool `json:"sameFinalCheck"`
+	// 协议对比：每个 http/https URL 同时检查另一协议的版本，输出两边的大小和是否一致，用于迁移审计
+	CompareSchemes bool `json:"compareSchemes"`
+	// Excel 模板文件，设置后在模板的当前工作表中写入结果，模板中已有的内容（如标志、标题）保持不变。
+	// ExcelStartRow、ExcelStartCol 为表头所在的行和列（从 1 开始），为 0 时使用 1，即从 A1 开始
+	ExcelTemplate string `json:"excelTemplate"`
+	ExcelStartRow int    `json:"excelStartRow"`
+	ExcelStartCol int    `json:"excelStartCol"`
+	// 是否将 Excel 中的 URL 单元格设为超链接。上万行时打开文件会明显变慢，默认关闭
+	ExcelHyperlinks bool `json:"excelHyperlinks"`
+	// 是否输出检查时间列，合并多次运行的结果时据此保留最新的记录
+	TimestampColumn bool `json:"timestampColumn"`
+	// 输出中时间的格式，按 Go 的时间格式书写，如 "2006-01-02 15:04:05"，为空时使用 RFC3339。
+	// 用于检查时间列、RunInfo 工作表和 HTML 报告的生成时间；合并结果文件时检查时间列按该格式读取，不符时再按 RFC3339 读取
+	TimeFormat string `json:"timeFormat"`
+	// 文件大小列统一使用的单位（B、KB、MB、GB、TB 或 PB），为空时按大小自动选择单位。
+	// 设置后表头改为“文件大小(MB)”这样的形式，成功的结果只写数值，便于在表格中直接求和或作图；
+	// SizePrecision 为保留的小数位数，未设置时为 2。只影响文件大小和另一协议大小列，
+	// 原始字节数列不受影响；改名后的列在合并结果时不再读取，需要时请同时输出原始字节数列
+	SizeUnit      string `json:"sizeUnit"`
+	SizePrecision *int   `json:"sizePrecision"`
+
+	// CSV/HTML 等文本输出中字节数按地区加千位分隔符（如 1,572,864,000），
+	// Excel 中仍写入数字，由 Excel 自行分组显示
+	GroupDigits bool   `json:"groupDigits"`
+	Locale      string `json:"locale"` // BCP 47 地区标识，如 en、zh-CN、de，为空时使用 en
+
+	// Prometheus 指标地址，如 :9090，设置后在该地址的 /metrics 提供请求数、失败数、
+	// 重试数、请求耗时和文件大小等指标；为空时不启用。指标在进程内累计，之后的检查改了地址时换到新地址提供
+	MetricsAddr string `json:"metricsAddr"`
+
+	// 检查结束（完成、取消或出错）后向该地址 POST 一个 JSON，包含状态、输出路径、统计和错误信息，
+	// 用于无人值守的定时任务。WebhookTimeout 为通知请求的超时，为 0 时使用默认值 10s。
+	// 通知失败只发出警告，不影响检查本身的结果
+	WebhookURL     string   `json:"webhookURL"`
+	WebhookTimeout Duration `json:"webhookTimeout"`
+
+	// 库调用方的自定义监控钩子，不能通过配置文件设置。两者都在检查的 goroutine 中调用，
+	// 会被多个 goroutine 同时调用，必须是并发安全的，且应尽快返回以免拖慢检查。
+	// OnRequestStart 在每个 HTTP 请求发出前调用（含重试和回退请求），OnResult 在每个 URL 检查完成后调用
+	OnRequestStart func(url string) `json:"-"`
+	OnResult       func(Result)     `json:"-"`
+
+	// 替换检查单个目标的实现，为空时发送真实的网络请求。不能通过配置文件设置，
+	// 替换后请求数、重试等依赖真实请求的统计为 0
+	Checker SizeChecker `json:"-"`
+
+	// 软失败检测：返回 200 但内容是小于阈值的 HTML 页面时标记为疑似软失败，
+	// 常见于重定向到登录页或错误页，配合 FinalURL 排查
+	SoftFailCheck     bool  `json:"softFailCheck"`
+	SoftFailThreshold int64 `json:"softFailThreshold"` // 字节数，为 0 时使用默认值 16 KB
+}
+
+// withDefaults 返回填充了默认值的选项副本
+func (o Options) withDefaults() Options {
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaultConcurrency
+	}
+	if o.MaxConcurrency <= 0 {
+		o.MaxConcurrency = defaultMaxConcurrency
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = Duration(defaultTimeout)
+	}
+	if o.ConnectTimeout <= 0 {
+		o.ConnectTimeout = Duration(defaultConnectTimeout)
+	}
+	if o.BestEffortSize {
+		o.SizeStrategy = StrategyAuto
+		if o.MaxBodyBytes <= 0 {
+			o.MaxBodyBytes = defaultBestEffortMaxBody
+		}
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = defaultBatchSize
+	}
+	if o.RetryStatusCodes == nil {
+		o.RetryStatusCodes = []int{429, 500, 502, 503, 504}
+	}
+	if o.RetryNetworkErrors == nil {
+		retry := true
+		o.RetryNetworkErrors = &retry
+	}
+	if o.DNSCacheTTL <= 0 {
+		o.DNSCacheTTL = Duration(defaultDNSCacheTTL)
+	}
+	if o.MaxIdleConns <= 0 {
+		o.MaxIdleConns = defaultMaxIdleConns
+	}
+	if o.MaxIdleConnsPerHost <= 0 {
+		// Go 默认每个主机只保留 2 个空闲连接，高并发访问同一主机时会频繁重建连接
+		o.MaxIdleConnsPerHost = min(o.Concurrency, o.MaxConcurrency)
+	}
+	if o.IdleConnTimeout <= 0 {
+		o.IdleConnTimeout = Duration(defaultIdleConnTimeout)
+	}
+	if o.ExcelStartRow <= 0 {
+		o.ExcelStartRow = 1
+	}
+	if o.ExcelStartCol <= 0 {
+		o.ExcelStartCol = 1
+	}
+	if o.Locale == "" {
+		o.Locale = "en"
+	}
+	if o.SoftFailThreshold <= 0 {
+		o.SoftFailThreshold = defaultSoftFailThreshold
+	}
+	return o
+}
+
+// clampConcurrency 将并发数限制在 MaxConcurrency 以内，opts 应已填充默认值，
+// 返回限制后的选项和是否发生了限制
+func (o Options) clampConcurrency() (Options, bool) {
+	if o.Concurrency <= o.MaxConcurrency {
+		return o, false
+	}
+	o.Concurrency = o.MaxConcurrency
+	return o, true
+}
+
+// clampOpenFiles 按 MaxOpenFiles 限制并发数和空闲连接数，使同时打开的连接（进行中的加空闲的）
+// 不超过预算，opts 应已填充默认值。返回限制后的选项和是否发生了限制
+func (o Options) clampOpenFiles() (Options, bool) {
+	if o.MaxOpenFiles <= 0 {
+		return o, false
+	}
+	conns := o.MaxOpenFiles - reservedOpenFiles
+	clamped := false
+	if limit := max(conns/2, 1); o.Concurrency > limit {
+		o.Concurrency = limit
+		clamped = true
+	}
+	if idle := max(conns-o.Concurrency, 1); o.MaxIdleConns > idle {
+		o.MaxIdleConns = idle
+		clamped = true
+	}
+	o.MaxIdleConnsPerHost = min(o.MaxIdleConnsPerHost, o.MaxIdleConns)
+	return o, clamped
+}
+
+// validate 校验选项取值
+func (o Options) validate() error {
+	switch o.OutputFilter {
+	case FilterAll, FilterSuccessOnly, FilterFailuresOnly:
+	default:
+		return fmt.Errorf("未知的输出过滤方式: %q", o.OutputFilter)
+	}
+
+	for _, pattern := range append(slices.Clone(o.AllowHosts), o.DenyHosts...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("无效的主机模式 %q: %w", pattern, err)
+		}
+	}
+
+	if o.ShardCount < 0 || o.ShardIndex < 0 || (o.ShardCount > 0 && o.ShardIndex >= o.ShardCount) {
+		return fmt.Errorf("无效的分片: %d/%d", o.ShardIndex, o.ShardCount)
+	}
+
+	if o.Repeat < 0 {
+		return errors.New("检查次数不能为负数")
+	}
+
+	if o.MaxBandwidth < 0 {
+		return errors.New("带宽上限不能为负数")
+	}
+	if strings.ContainsAny(o.HostHeader, " /") {
+		return fmt.Errorf("无效的 Host 请求头: %q", o.HostHeader)
+	}
+	if o.BreakerThreshold < 0 || o.BreakerCooldown < 0 {
+		return errors.New("熔断阈值和冷却时间不能为负数")
+	}
+	if err := checkGzipFormat(o.OutputFile); err != nil {
+		return err
+	}
+	if o.MaxOpenFiles < 0 || (o.MaxOpenFiles > 0 && o.MaxOpenFiles <= reservedOpenFiles) {
+		return fmt.Errorf("文件描述符预算应大于 %d", reservedOpenFiles)
+	}
+	if o.TopN < 0 {
+		return errors.New("TopN 不能为负数")
+	}
+	if o.FlushEvery < 0 || o.FlushInterval < 0 {
+		return errors.New("写入中间结果的间隔不能为负数")
+	}
+	if o.AssumedBandwidth < 0 {
+		return errors.New("假定带宽不能为负数")
+	}
+	if o.SizeWeightBytes < 0 {
+		return errors.New("并发加权的字节数不能为负数")
+	}
+
+	if _, ok := sizeUnits[o.SizeUnit]; o.SizeUnit != "" && !ok {
+		return fmt.Errorf("不支持的大小单位: %q，应为 B、KB、MB、GB、TB 或 PB", o.SizeUnit)
+	}
+	if o.SizePrecision != nil && (*o.SizePrecision < 0 || *o.SizePrecision > 10) {
+		return errors.New("大小的小数位数应在 0 到 10 之间")
+	}
+	if o.TimeFormat != "" && !validTimeLayout(o.TimeFormat) {
+		return fmt.Errorf("无效的时间格式 %q，应按 Go 的时间格式书写，如 2006-01-02 15:04:05", o.TimeFormat)
+	}
+
+	if o.SizeTolerance < 0 || o.SizeTolerancePercent < 0 {
+		return errors.New("大小允许误差不能为负数")
+	}
+
+	if o.JitterMin < 0 || o.JitterMax < o.JitterMin {
+		return fmt.Errorf("无效的随机等待范围: %s - %s", time.Duration(o.JitterMin), time.Duration(o.JitterMax))
+	}
+
+	seen := make(map[string]bool)
+	for _, h := range o.Columns {
+		if _, ok := columnByHeader(h); !ok {
+			return fmt.Errorf("未知的输出列: %q", h)
+		}
+		if seen[h] {
+			return fmt.Errorf("输出列重复: %q", h)
+		}
+		seen[h] = true
+	}
+
+	switch o.SortBy {
+	case SortBySize, SortByURL, SortByStatus, SortByContentType, SortByDuration:
+	default:
+		return fmt.Errorf("未知的排序依据: %q", o.SortBy)
+	}
+	switch o.SortOrder {
+	case SortDefault, SortAsc, SortDesc:
+	default:
+		return fmt.Errorf("未知的排序方向: %q", o.SortOrder)
+	}
+
+	switch o.SizeStrategy {
+	case StrategyHead, StrategyGet, StrategyAuto:
+	case StrategyOptions:
+		if o.SizeHeader == "" {
+			return errors.New("options 策略需要指定 sizeHeader")
+		}
+	default:
+		return fmt.Errorf("未知的取大小策略: %q", o.SizeStrategy)
+	}
+
+	for _, step := range o.Strategies {
+		switch step {
+		case StrategyHeadStep, StrategyRangeStep, StrategyGet:
+		case StrategyOptions:
+			if o.SizeHeader == "" {
+				return errors.New("options 策略需要指定 sizeHeader")
+			}
+		default:
+			return fmt.Errorf("回退链中未知的取大小方式: %q", step)
+		}
+	}
+
+	if o.ExcelTemplate != "" {
+		if _, err := os.Stat(o.ExcelTemplate); err != nil {
+			return fmt.Errorf("Excel 模板不可用: %w", err)
+		}
+	}
+
+	if _, err := language.Parse(o.Locale); err != nil {
+		return fmt.Errorf("无效的地区标识 %q: %w", o.Locale, err)
+	}
+
+	return nil
+}
+
+// loadOptions 从 JSON 配置文件加载选项
+func loadOptions(path string) (Options, error) {
+	var opts Options
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return opts, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields() // 拼错的配置项直接报错，避免静默忽略
+	if err := decoder.Decode(&opts); err != nil {
+		return opts, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	return opts, nil
+}
+
+// LoadConfig 加载 JSON 配置文件，作为后续检查的选项
+func (a *App) LoadConfig(path string) error {
+	opts, err := loadOptions(path)
+	if err != nil {
+		return err
+	}
+	a.SetOptions(opts)
+	return nil
+}
+
+// SetOptions 设置后续检查使用的选项
+func (a *App) SetOptions(opts Options) {
+	a.mu.Lock()
+	a.opts = opts
+	a.mu.Unlock()
+}
+
+// options 返回当前选项的副本
+func (a *App) options() Options {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.opts
+}
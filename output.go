@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// writeOutput 按输出文件的扩展名选择格式写入结果，默认写入 Excel。stats 写入 JSON 输出和 Excel 的 RunInfo 工作表。
+// 文本格式的路径以 .gz 结尾时（如 results.csv.gz）压缩写入
+func writeOutput(results []Result, stats Stats, byType []TypeSummary, byHost []HostSummary, outputPath string, opts Options) error {
+	if err := checkGzipFormat(outputPath); err != nil {
+		return err
+	}
+	columns := outputColumns(opts)
+
+	switch outputFormat(outputPath) {
+	case ".db", ".sqlite":
+		return writeToSQLite(results, outputPath)
+	case ".html", ".htm":
+		return writeToHTML(results, columns, outputPath, opts)
+	case ".csv":
+		return writeToCSV(results, columns, outputPath, newNumberPrinter(opts), ',', opts.CSVBOM)
+	case ".tsv":
+		return writeToCSV(results, columns, outputPath, newNumberPrinter(opts), '\t', opts.CSVBOM)
+	case ".json":
+		return writeToJSON(results, stats, outputPath)
+	default:
+		return writeToExcel(results, columns, stats, byType, byHost, outputPath, opts)
+	}
+}
+
+// newNumberPrinter 创建按地区格式化数字的 Printer，未启用千位分隔时返回 nil
+func newNumberPrinter(opts Options) *message.Printer {
+	if !opts.GroupDigits {
+		return nil
+	}
+	// 地区已在 validate 中校验过
+	tag, _ := language.Parse(opts.Locale)
+	return message.NewPrinter(tag)
+}
+
+// cellText 将结果在该列的值转为文本。printer 不为空时，只有标记了 grouped 的列（字节数）
+// 按地区规则加千位分隔符，其他整数列（如输入位置）保持原样，以便合并结果时读回
+func cellText(col column, r Result, printer *message.Printer) string {
+	v := col.value(r)
+	if printer != nil && col.grouped {
+		switch n := v.(type) {
+		case int, int64:
+			return printer.Sprintf("%d", n)
+		}
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// progressBar 命令行的节流进度显示。终端中在同一行用 \r 刷新进度条，
+// 输出被重定向时退化为定期打印一行
+type progressBar struct {
+	w        io.Writer
+	tty      bool
+	interval time.Duration
+	start    time.Time
+	last     time.Time
+}
+
+// newProgressBar 创建写入 stderr 的进度条
+func newProgressBar() *progressBar {
+	tty := isTerminal(os.Stderr)
+	interval := 5 * time.Second
+	if tty {
+		interval = 200 * time.Millisecond
+	}
+	return &progressBar{w: os.Stderr, tty: tty, interval: interval, start: time.Now()}
+}
+
+// update 更新进度，距上次输出不足间隔时跳过；调用方需保证串行调用
+func (p *progressBar) update(completed, total int) {
+	now := time.Now()
+	if completed < total && now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+
+	percent := completed * 100 / total
+	eta := "--"
+	if completed > 0 && completed < total {
+		elapsed := now.Sub(p.start)
+		remaining := elapsed / time.Duration(completed) * time.Duration(total-completed)
+		eta = remaining.Round(time.Second).String()
+	} else if completed == total {
+		eta = "0s"
+	}
+
+	if !p.tty {
+		fmt.Fprintf(p.w, "进度 %d%% (%d/%d)，预计剩余 %s\n", percent, completed, total, eta)
+		return
+	}
+
+	const width = 30
+	filled := width * completed / total
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+	fmt.Fprintf(p.w, "\r[%s] %3d%% %d/%d 预计剩余 %s\033[K", bar, percent, completed, total, eta)
+}
+
+// finish 结束进度条所在的行
+func (p *progressBar) finish() {
+	if p.tty && !p.last.IsZero() {
+		fmt.Fprintln(p.w)
+	}
+}
+
+// isTerminal 判断文件是否连接到终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
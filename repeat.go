@@ -0,0 +1,36 @@
+package main
+
+import "context"
+
+// repeatProbes 第一次检查成功后在同一个 worker 中再检查 Repeat-1 次，因此不额外占用并发数，
+// 每次请求同样受 HostDelay 和随机等待限制。记录各次大小的最小值、最大值和众数（次数相同时取较小的），
+// 所有检查都成功且大小相同时为稳定。r 的其余字段保持第一次检查的结果
+func (c *checker) repeatProbes(ctx context.Context, t Target, r *Result) {
+	counts := map[int64]int{r.Bytes: 1}
+	r.MinBytes, r.MaxBytes = r.Bytes, r.Bytes
+	r.Stable = true
+	for i := 1; i < c.opts.Repeat; i++ {
+		if ctx.Err() != nil {
+			r.Stable = false
+			break
+		}
+		probe := c.checkOne(ctx, t)
+		if probe.Failed() {
+			r.Stable = false
+			continue
+		}
+		counts[probe.Bytes]++
+		r.MinBytes = min(r.MinBytes, probe.Bytes)
+		r.MaxBytes = max(r.MaxBytes, probe.Bytes)
+	}
+	if r.MinBytes != r.MaxBytes {
+		r.Stable = false
+	}
+
+	best := 0
+	for size, n := range counts {
+		if n > best || (n == best && size < r.ModeBytes) {
+			r.ModeBytes, best = size, n
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeRunInfoSheet 写入 RunInfo 工作表，记录本次运行的时间、版本、主要选项和统计，
+// 归档的表格不依赖其他记录也能知道是怎样得到的
+func writeRunInfoSheet(excel *excelize.File, stats Stats, opts Options) error {
+	sheetName := "RunInfo"
+	if _, err := excel.NewSheet(sheetName); err != nil {
+		return err
+	}
+
+	strategy := string(opts.SizeStrategy)
+	if strategy == "" {
+		strategy = "head"
+	}
+	rows := [][2]interface{}{
+		{"开始时间", formatTimeLayout(stats.StartedAt, opts.TimeFormat)},
+		{"工具版本", version},
+		{"并发数", opts.Concurrency},
+		{"超时", time.Duration(opts.Timeout).String()},
+		{"重试次数", opts.Retries},
+		{"取大小策略", strategy},
+		{"URL 总数", stats.Total},
+		{"成功", stats.Succeeded},
+		{"失败", stats.Failed},
+		{"总字节数", stats.TotalBytes},
+		{"总大小", formatFileSize(stats.TotalBytes)},
+		{"耗时", time.Duration(stats.Elapsed).Round(time.Millisecond).String()},
+		{"请求数", stats.Requests},
+		{"重试数", stats.Retries},
+	}
+	if stats.EstimatedDownload > 0 {
+		rows = append(rows, [2]interface{}{
+			"预计下载时间",
+			fmt.Sprintf("%s（按 %s/s）", time.Duration(stats.EstimatedDownload).Round(time.Second), formatFileSize(opts.AssumedBandwidth)),
+		})
+	}
+	for i, row := range rows {
+		excel.SetCellValue(sheetName, fmt.Sprintf("A%d", i+1), row[0])
+		if err := setExcelCell(excel, sheetName, fmt.Sprintf("B%d", i+1), row[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sizeBucket 大小分布图的一个区间，包含下限、不含上限，上限为 0 表示不设上限
+type sizeBucket struct {
+	label    string
+	min, max int64
+}
+
+// sizeBuckets 大小分布图的区间
+var sizeBuckets = []sizeBucket{
+	{"< 1 MB", 0, 1 << 20},
+	{"1–10 MB", 1 << 20, 10 << 20},
+	{"10–100 MB", 10 << 20, 100 << 20},
+	{"100 MB–1 GB", 100 << 20, 1 << 30},
+	{"≥ 1 GB", 1 << 30, 0},
+}
+
+// countBySize 按 sizeBuckets 统计成功结果的个数
+func countBySize(results []Result) []int {
+	counts := make([]int, len(sizeBuckets))
+	for _, r := range results {
+		if r.Failed() {
+			continue
+		}
+		for i, b := range sizeBuckets {
+			if r.Bytes >= b.min && (b.max == 0 || r.Bytes < b.max) {
+				counts[i]++
+				break
+			}
+		}
+	}
+	return counts
+}
+
+// writeSizeChartSheet 写入 SizeChart 工作表：按大小区间统计的文件数及对应的柱状图，不影响结果工作表
+func writeSizeChartSheet(excel *excelize.File, results []Result) error {
+	sheetName := "SizeChart"
+	if _, err := excel.NewSheet(sheetName); err != nil {
+		return err
+	}
+	excel.SetCellValue(sheetName, "A1", "大小范围")
+	excel.SetCellValue(sheetName, "B1", "文件数")
+	for i, n := range countBySize(results) {
+		excel.SetCellValue(sheetName, fmt.Sprintf("A%d", i+2), sizeBuckets[i].label)
+		excel.SetCellValue(sheetName, fmt.Sprintf("B%d", i+2), n)
+	}
+
+	last := len(sizeBuckets) + 1
+	return excel.AddChart(sheetName, "D2", &excelize.Chart{
+		Type: excelize.Col,
+		Series: []excelize.ChartSeries{{
+			Name:       fmt.Sprintf("%s!$B$1", sheetName),
+			Categories: fmt.Sprintf("%s!$A$2:$A$%d", sheetName, last),
+			Values:     fmt.Sprintf("%s!$B$2:$B$%d", sheetName, last),
+		}},
+		Title:  []excelize.RichTextRun{{Text: "文件大小分布"}},
+		Legend: excelize.ChartLegend{Position: "none"},
+	})
+}
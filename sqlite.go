@@ -0,0 +1,63 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite 结果表结构，多次运行的结果追加到同一张表中，便于按时间查询历史
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS results (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	url          TEXT    NOT NULL,
+	size         TEXT    NOT NULL,
+	bytes        INTEGER NOT NULL,
+	status_code  INTEGER NOT NULL,
+	content_type TEXT    NOT NULL,
+	final_url    TEXT    NOT NULL,
+	error        TEXT    NOT NULL,
+	checked_at   TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_results_url ON results (url, checked_at);
+`
+
+// writeToSQLite 将结果写入 SQLite 数据库，表不存在时自动创建
+func writeToSQLite(results []Result, outputPath string) error {
+	db, err := sql.Open("sqlite", outputPath)
+	if err != nil {
+		return fmt.Errorf("打开数据库失败: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("创建数据表失败: %w", err)
+	}
+
+	// 整批结果在一个事务中写入
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO results
+		(url, size, bytes, status_code, content_type, final_url, error, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, r := range results {
+		_, err := stmt.Exec(r.URL, r.Size, r.Bytes, r.StatusCode, r.ContentType, r.FinalURL, r.Err,
+			r.CheckedAt.UTC().Format(time.RFC3339Nano))
+		if err != nil {
+			return fmt.Errorf("写入数据库失败: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stats 一次检查的统计
+type Stats struct {
+	Total      int      `json:"total"`      // URL 总数
+	Succeeded  int      `json:"succeeded"`  // 成功数
+	Failed     int      `json:"failed"`     // 失败数
+	TotalBytes int64    `json:"totalBytes"` // 成功结果的文件大小合计
+	Elapsed    Duration `json:"elapsed"`    // 耗时
+	Requests   int64    `json:"requests"`   // 实际发出的 HTTP 请求数，含重试和 HEAD 回退
+	BytesRead  int64    `json:"bytesRead"`  // 实际读取的响应体字节数
+	Retries    int64    `json:"retries"`    // 实际进行的重试次数
+	Insecure   int      `json:"insecure"`   // 使用明文 HTTP 的 URL 数（含失败的）
+
+	// 按失败原因统计的失败数，键为失败类型（如 timeout、dns），HTTP 状态码错误按状态码分开记为 status_403 等
+	Failures map[string]int `json:"failures,omitempty"`
+	// 本次检查中熔断过的主机，按名称排序，见 BreakerThreshold
+	TrippedHosts []string `json:"trippedHosts,omitempty"`
+
+	StartedAt time.Time `json:"startedAt"` // 检查开始的时间，合并结果时为零值
+
+	// 按 AssumedBandwidth 估算的下载全部成功文件（TotalBytes）所需的时间，未设置带宽时为 0
+	EstimatedDownload Duration `json:"estimatedDownload"`
+
+	// 单个 URL 检查耗时（含重试）的百分位数，按最近秩法计算。低内存模式下不保留结果，这三项为 0
+	P50 Duration `json:"p50"`
+	P90 Duration `json:"p90"`
+	P99 Duration `json:"p99"`
+}
+
+// DoneEvent 检查完成时随 done 事件发送的内容
+type DoneEvent struct {
+	OutputPath string `json:"outputPath"`
+	Stats      Stats  `json:"stats"`
+}
+
+// ErrorEvent 检查出错或被取消时随 error 事件发送的内容
+type ErrorEvent struct {
+	Kind    string `json:"kind"`    // 错误类型，见 KindCancelled 等
+	Message string `json:"message"` // 错误信息
+	Stats   Stats  `json:"stats"`   // 已完成部分的统计，检查开始前出错时为零值
+}
+
+// computeStats 根据结果计算统计
+func computeStats(results []Result, elapsed time.Duration) Stats {
+	stats := Stats{Elapsed: Duration(elapsed)}
+	stats.add(results)
+
+	// 未派发的 URL 没有耗时，不参与计算
+	durations := make([]Duration, 0, len(results))
+	for _, r := range results {
+		if r.Elapsed > 0 {
+			durations = append(durations, r.Elapsed)
+		}
+	}
+	slices.Sort(durations)
+	stats.P50 = percentile(durations, 50)
+	stats.P90 = percentile(durations, 90)
+	stats.P99 = percentile(durations, 99)
+	return stats
+}
+
+// percentile 返回已排序切片的第 p 百分位数（最近秩法），切片为空时返回 0
+func percentile(sorted []Duration, p int) Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // 向上取整
+	return sorted[max(rank, 1)-1]
+}
+
+// add 将一批结果计入统计，低内存模式下逐批累计
+func (s *Stats) add(results []Result) {
+	s.Total += len(results)
+	for _, r := range results {
+		if r.Insecure {
+			s.Insecure++
+		}
+		if r.Failed() {
+			s.Failed++
+			if s.Failures == nil {
+				s.Failures = make(map[string]int)
+			}
+			s.Failures[failureKey(r)]++
+		} else {
+			s.Succeeded++
+			s.TotalBytes += r.Bytes
+		}
+	}
+}
+
+// estimateDownload 估算以 bandwidth 字节/秒下载 totalBytes 字节所需的时间，bandwidth 不大于 0 时返回 0。
+// 按整数分别计算整秒和余下的部分，避免大文件换算为浮点数时丢失精度
+func estimateDownload(totalBytes, bandwidth int64) Duration {
+	if bandwidth <= 0 {
+		return 0
+	}
+	secs, rem := totalBytes/bandwidth, totalBytes%bandwidth
+	return Duration(time.Duration(secs)*time.Second + time.Duration(rem)*time.Second/time.Duration(bandwidth))
+}
+
+// failureKey 返回失败结果在 Stats.Failures 中的键。从 CSV 等不带失败类型的结果读入时，
+// 有状态码的按状态码计，否则计为 other
+func failureKey(r Result) string {
+	if r.StatusCode != 0 && (r.FailKind == "status" || r.FailKind == "") {
+		return "status_" + strconv.Itoa(r.StatusCode)
+	}
+	if r.FailKind == "" {
+		return "other"
+	}
+	return r.FailKind
+}
+
+// failureSummary 按数量从多到少列出失败原因，如 timeout 12，dns 5，status_403 3，没有失败时返回空串
+func (s Stats) failureSummary() string {
+	keys := make([]string, 0, len(s.Failures))
+	for k := range s.Failures {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		if s.Failures[a] != s.Failures[b] {
+			return s.Failures[b] - s.Failures[a]
+		}
+		return strings.Compare(a, b)
+	})
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + " " + strconv.Itoa(s.Failures[k])
+	}
+	return strings.Join(parts, "，")
+}
+
+// LastStats 返回最近一次检查的统计
+func (a *App) LastStats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stats
+}
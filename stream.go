@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/message"
+)
+
+// resultWriter 分批写入结果的输出，低内存模式下每检查完一批就写入一批，不保留已写入的结果
+type resultWriter interface {
+	write(results []Result) error
+	Close() error
+}
+
+// newResultWriter 按输出文件的扩展名创建分批写入的输出，只支持 CSV、TSV 和 Excel。
+// outputPath 为空时丢弃结果，只用于统计
+func newResultWriter(outputPath string, opts Options) (resultWriter, error) {
+	if outputPath == "" {
+		return discardWriter{}, nil
+	}
+	if err := checkGzipFormat(outputPath); err != nil {
+		return nil, err
+	}
+	columns := outputColumns(opts)
+
+	switch ext := outputFormat(outputPath); ext {
+	case ".csv":
+		return newCSVStreamWriter(outputPath, columns, newNumberPrinter(opts), ',', opts.CSVBOM)
+	case ".tsv":
+		return newCSVStreamWriter(outputPath, columns, newNumberPrinter(opts), '\t', opts.CSVBOM)
+	case ".db", ".sqlite", ".html", ".htm", ".json":
+		return nil, fmt.Errorf("低内存模式只支持 CSV、TSV 和 Excel 输出，不支持 %s", ext)
+	default:
+		return newExcelStreamWriter(outputPath, columns)
+	}
+}
+
+// discardWriter 不写入任何内容的输出
+type discardWriter struct{}
+
+func (discardWriter) write([]Result) error { return nil }
+
+func (discardWriter) Close() error { return nil }
+
+// csvStreamWriter 分批写入的 CSV/TSV 输出
+type csvStreamWriter struct {
+	file    io.WriteCloser
+	w       *csv.Writer
+	columns []column
+	printer *message.Printer
+}
+
+// newCSVStreamWriter 创建输出文件并写入表头，bom 为 true 时先写入 UTF-8 BOM
+func newCSVStreamWriter(outputPath string, columns []column, printer *message.Printer, comma rune, bom bool) (*csvStreamWriter, error) {
+	file, err := createOutput(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	if bom {
+		if _, err := io.WriteString(file, utf8BOM); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	sw := &csvStreamWriter{file: file, w: csv.NewWriter(file), columns: columns, printer: printer}
+	sw.w.Comma = comma
+	record := make([]string, len(columns))
+	for j, col := range columns {
+		record[j] = col.header
+	}
+	if err := sw.w.Write(record); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return sw, nil
+}
+
+func (sw *csvStreamWriter) write(results []Result) error {
+	record := make([]string, len(sw.columns))
+	for _, r := range results {
+		for j, col := range sw.columns {
+			record[j] = cellText(col, r, sw.printer)
+		}
+		if err := sw.w.Write(record); err != nil {
+			return err
+		}
+	}
+	// 每批写完立即落盘，中途退出时已写入的部分仍然可用；压缩输出同时刷新 gzip 流，已写入的部分可以解压
+	sw.w.Flush()
+	if err := sw.w.Error(); err != nil {
+		return err
+	}
+	if gz, ok := sw.file.(*gzipFile); ok {
+		return gz.Flush()
+	}
+	return nil
+}
+
+func (sw *csvStreamWriter) Close() error {
+	sw.w.Flush()
+	if err := sw.w.Error(); err != nil {
+		sw.file.Close()
+		return err
+	}
+	return sw.file.Close()
+}
+
+// excelStreamWriter 通过 excelize.StreamWriter 分批写入的 Excel 输出。
+// 行数据超过一定大小后由 excelize 暂存到临时文件，内存占用有上限；不支持超链接
+type excelStreamWriter struct {
+	excel      *excelize.File
+	sw         *excelize.StreamWriter
+	columns    []column
+	bytesStyle int
+	row        int
+	outputPath string
+}
+
+// newExcelStreamWriter 创建工作簿并写入表头
+func newExcelStreamWriter(outputPath string, columns []column) (_ *excelStreamWriter, err error) {
+	excel := excelize.NewFile()
+	defer func() {
+		if err != nil {
+			excel.Close()
+		}
+	}()
+	sheetName := "Results"
+	excel.SetSheetName(excel.GetSheetName(0), sheetName)
+
+	sw, err := excel.NewStreamWriter(sheetName)
+	if err != nil {
+		return nil, err
+	}
+	format := "#,##0"
+	bytesStyle, err := excel.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(columns))
+	for j, col := range columns {
+		header[j] = col.header
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return nil, err
+	}
+
+	return &excelStreamWriter{
+		excel:      excel,
+		sw:         sw,
+		columns:    columns,
+		bytesStyle: bytesStyle,
+		row:        1,
+		outputPath: outputPath,
+	}, nil
+}
+
+func (ew *excelStreamWriter) write(results []Result) error {
+	values := make([]interface{}, len(ew.columns))
+	for _, r := range results {
+		ew.row++
+		for j, col := range ew.columns {
+			values[j] = col.value(r)
+			if col.header == "字节数" {
+				values[j] = excelize.Cell{StyleID: ew.bytesStyle, Value: values[j]}
+			}
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, ew.row)
+		if err := ew.sw.SetRow(cell, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 保存工作簿。保存失败时同样关闭工作簿，释放 excelize 暂存行数据的临时文件
+func (ew *excelStreamWriter) Close() error {
+	err := ew.sw.Flush()
+	if err == nil {
+		err = ew.excel.SaveAs(ew.outputPath)
+	}
+	if closeErr := ew.excel.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
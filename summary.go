@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 无法识别内容类型时使用的分组名
+const unknownContentType = "(未知)"
+
+// TypeSummary 按内容类型汇总的统计
+type TypeSummary struct {
+	ContentType string // 不含参数的媒体类型，如 application/pdf
+	Count       int    // 成功检查的 URL 数
+	TotalBytes  int64  // 文件大小合计
+}
+
+// mediaType 去掉 Content-Type 中的参数（如 charset）并转为小写
+func mediaType(contentType string) string {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mt
+	}
+	mt, _, _ := strings.Cut(contentType, ";")
+	mt = strings.ToLower(strings.TrimSpace(mt))
+	if mt == "" {
+		return unknownContentType
+	}
+	return mt
+}
+
+// summarizeByType 按内容类型汇总成功的结果，按总大小倒序排列
+func summarizeByType(results []Result) []TypeSummary {
+	index := make(map[string]int)
+	var summaries []TypeSummary
+
+	for _, r := range results {
+		if r.Failed() {
+			continue
+		}
+		mt := mediaType(r.ContentType)
+		i, ok := index[mt]
+		if !ok {
+			i = len(summaries)
+			index[mt] = i
+			summaries = append(summaries, TypeSummary{ContentType: mt})
+		}
+		summaries[i].Count++
+		summaries[i].TotalBytes += r.Bytes
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].TotalBytes != summaries[j].TotalBytes {
+			return summaries[i].TotalBytes > summaries[j].TotalBytes
+		}
+		return summaries[i].ContentType < summaries[j].ContentType
+	})
+	return summaries
+}
+
+// writeTypeSheet 将按类型汇总写入单独的 ByType 工作表
+func writeTypeSheet(excel *excelize.File, summaries []TypeSummary) error {
+	sheetName := "ByType"
+	if _, err := excel.NewSheet(sheetName); err != nil {
+		return err
+	}
+	excel.SetCellValue(sheetName, "A1", "内容类型")
+	excel.SetCellValue(sheetName, "B1", "数量")
+	excel.SetCellValue(sheetName, "C1", "总字节数")
+	excel.SetCellValue(sheetName, "D1", "总大小")
+
+	for i, s := range summaries {
+		row := i + 2
+		excel.SetCellValue(sheetName, fmt.Sprintf("A%d", row), s.ContentType)
+		excel.SetCellValue(sheetName, fmt.Sprintf("B%d", row), s.Count)
+		excel.SetCellValue(sheetName, fmt.Sprintf("C%d", row), s.TotalBytes)
+		excel.SetCellValue(sheetName, fmt.Sprintf("D%d", row), formatFileSize(s.TotalBytes))
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// bandwidthLimiter 所有 worker 共用的带宽限速器。每读到一段数据就按速率顺延下一次允许读取的时间，
+// 与 waitHost 一样在锁内预约、锁外等待
+type bandwidthLimiter struct {
+	rate int64 // 字节/秒
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait 为刚读到的 n 个字节预约时间，需要时等待，ctx 结束时提前返回
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	l.mu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttle 未设置 MaxBandwidth 时原样返回 r，否则包装为限速读取
+func (c *checker) throttle(ctx context.Context, r io.Reader) io.Reader {
+	if c.bandwidth == nil {
+		return r
+	}
+	return &throttledReader{ctx: ctx, r: r, limiter: c.bandwidth}
+}
+
+// throttledReader 限速读取的 Reader。每次最多读取约 0.1 秒的数据量，避免一次大块读取造成突发
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if chunk := int(max(t.limiter.rate/10, 1)); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if werr := t.limiter.wait(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
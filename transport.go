@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// newHTTPClient 根据选项创建 HTTP 客户端，opts 应已填充默认值
+func newHTTPClient(opts Options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = opts.MaxIdleConns
+	transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = time.Duration(opts.IdleConnTimeout)
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+
+	if opts.DisableHTTP2 {
+		// 非 nil 的空 TLSNextProto 会阻止 TLS 握手时协商 h2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	if serverName := tlsServerName(opts); serverName != "" {
+		// Clone 后 TLSClientConfig 可能为 nil
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.ServerName = serverName
+	}
+
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("代理地址无效: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	// 连接超时只限制建立 TCP 连接，整个请求的超时由 context 控制
+	dialer := &net.Dialer{Timeout: time.Duration(opts.ConnectTimeout), KeepAlive: 30 * time.Second}
+	transport.DialContext = dialer.DialContext
+	if opts.DNSCache {
+		transport.DialContext = newDNSCache(time.Duration(opts.DNSCacheTTL)).dialContext(dialer)
+	}
+
+	// 超时由每个请求的 context 控制，以便单个 URL 覆盖全局超时
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect(opts)}, nil
+}
+
+// tlsServerName 返回 TLS 握手使用的 SNI：优先 TLSServerName，其次 HostHeader 去掉端口后的主机名
+func tlsServerName(opts Options) string {
+	if opts.TLSServerName != "" || opts.HostHeader == "" {
+		return opts.TLSServerName
+	}
+	if host, _, err := net.SplitHostPort(opts.HostHeader); err == nil {
+		return strings.Trim(host, "[]")
+	}
+	return strings.Trim(opts.HostHeader, "[]")
+}
+
+// redirectCountKey 请求 context 中重定向计数器的键
+type redirectCountKey struct{}
+
+// checkRedirect 返回客户端的重定向策略：AcceptStatusCodes 中的重定向状态码不再跟随，
+// 直接使用该响应；设置了 BearerToken 时，重定向到其他主机前移除 Authorization 请求头，
+// 避免令牌泄露给第三方；每次跟随时为请求 context 中的计数器加一，与默认行为一样最多跟随 10 次
+func checkRedirect(opts Options) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if slices.Contains(opts.AcceptStatusCodes, req.Response.StatusCode) {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= 10 {
+			return errors.New("重定向次数超过 10 次")
+		}
+
+		// 重定向的目标同样受主机过滤限制
+		if !hostAllowed(req.URL.Hostname(), opts) {
+			return errHostFiltered
+		}
+
+		if opts.BearerToken != "" && !strings.EqualFold(req.URL.Hostname(), via[0].URL.Hostname()) {
+			req.Header.Del("Authorization")
+		}
+
+		if n, ok := req.Context().Value(redirectCountKey{}).(*int); ok {
+			*n++
+		}
+		return nil
+	}
+}
+
+// dnsEntry DNS 缓存条目
+type dnsEntry struct {
+	ips     []net.IPAddr
+	expires time.Time
+}
+
+// dnsCache 进程内 DNS 缓存，同一次检查中相同主机只解析一次
+type dnsCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	entries  map[string]dnsEntry
+	resolver *net.Resolver
+}
+
+// newDNSCache 创建 DNS 缓存
+func newDNSCache(ttl time.Duration) *dnsCache {
+	return &dnsCache{
+		ttl:      ttl,
+		entries:  make(map[string]dnsEntry),
+		resolver: net.DefaultResolver,
+	}
+}
+
+// lookup 解析主机名，命中且未过期时直接返回缓存结果
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.ips, nil
+	}
+
+	// 解析时传入 ctx，遵守请求的超时和取消
+	ips, err := c.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, errors.New("DNS 解析结果为空")
+	}
+
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{ips: ips, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return ips, nil
+}
+
+// dialContext 返回使用缓存解析结果建立连接的 DialContext
+func (c *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		// IP 地址无需解析
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		ips, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		// 依次尝试解析到的地址，返回第一个成功的连接
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		return nil, lastErr
+	}
+}
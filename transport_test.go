@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serveHTTP10 启动一个只会 HTTP/1.0 的服务器：每个请求返回 Content-Length 为 size 的响应后关闭连接
+func serveHTTP10(t *testing.T, size int) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(conn, "HTTP/1.0 200 OK\r\nContent-Length: %d\r\nConnection: close\r\n\r\n", size)
+				if req.Method != http.MethodHead {
+					conn.Write(make([]byte, size))
+				}
+			}()
+		}
+	}()
+	return "http://" + ln.Addr().String()
+}
+
+func TestHTTP10ConnectionClose(t *testing.T) {
+	base := serveHTTP10(t, 1234)
+	baseline := runtime.NumGoroutine()
+
+	var urls []string
+	for i := 0; i < 20; i++ {
+		urls = append(urls, fmt.Sprintf("%s/file%d", base, i))
+	}
+	a := NewApp()
+	results, err := a.CheckFileSizeConcurrent(urls, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Failed() || r.Bytes != 1234 {
+			t.Fatalf("%s: Size = %s, Err = %s", r.URL, r.Size, r.Err)
+		}
+	}
+
+	// 服务器每次都关闭连接，检查结束后不应留下读写连接的 goroutine
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		buf := make([]byte, 1<<16)
+		t.Fatalf("检查结束后 goroutine 数为 %d，检查前为 %d\n%s", n, baseline, buf[:runtime.Stack(buf, true)])
+	}
+}
+
+func TestBearerTokenRedirects(t *testing.T) {
+	if _, err := net.LookupHost("localhost"); err != nil {
+		t.Skip("无法解析 localhost:", err)
+	}
+
+	var mu sync.Mutex
+	auth := make(map[string]string) // 主机和路径 -> 收到的 Authorization
+	record := func(r *http.Request) {
+		mu.Lock()
+		auth[r.Host+r.URL.Path] = r.Header.Get("Authorization")
+		mu.Unlock()
+	}
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.Header().Set("Content-Length", "10")
+	}))
+	defer other.Close()
+	// 同一地址换用 localhost 作为主机名，对检查器来说是另一个主机
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/cross":
+			http.Redirect(w, r, otherURL+"/elsewhere", http.StatusFound)
+		default:
+			w.Header().Set("Content-Length", "20")
+		}
+	}))
+	defer srv.Close()
+
+	a := NewApp()
+	a.SetOptions(Options{BearerToken: "secret"})
+	results, err := a.CheckFileSizeConcurrent([]string{srv.URL + "/same", srv.URL + "/cross"}, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Failed() {
+			t.Fatalf("%s: %s", r.URL, r.Err)
+		}
+	}
+
+	host := strings.TrimPrefix(srv.URL, "http://")
+	want := map[string]string{
+		host + "/same":  "Bearer secret",
+		host + "/final": "Bearer secret",
+		host + "/cross": "Bearer secret",
+		strings.TrimPrefix(otherURL, "http://") + "/elsewhere": "",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for path, v := range want {
+		got, ok := auth[path]
+		if !ok {
+			t.Errorf("%s 没有收到请求", path)
+		} else if got != v {
+			t.Errorf("%s 收到的 Authorization 为 %q，应为 %q", path, got, v)
+		}
+	}
+}
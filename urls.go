@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Target 待检查的目标，结构化输入中可以为单个 URL 指定设置
+type Target struct {
+	URL     string            `json:"url"`
+	Timeout Duration          `json:"timeout,omitempty"` // 单独的超时时间，为 0 时使用全局超时
+	Headers map[string]string `json:"headers,omitempty"` // 单独的请求头，与全局请求头同名时优先
+
+	// 预期的文件大小（字节），为 0 时不校验。与实际大小之差超出 SizeTolerance 和
+	// SizeTolerancePercent 允许的范围时标记为大小不符
+	ExpectedSize int64 `json:"expectedSize,omitempty"`
+
+	index int // 在输入中的位置，写入 Result.Index
+}
+
+// targetsFromURLs 将普通 URL 列表转换为目标列表
+func targetsFromURLs(urls []string) []Target {
+	targets := make([]Target, len(urls))
+	for i, u := range urls {
+		targets[i] = Target{URL: u, index: i}
+	}
+	return targets
+}
+
+// normalizeURL 校验并规范化输入的 URL
+// IPv6 字面量地址必须写在方括号中（如 http://[2001:db8::1]/file），方括号会原样保留
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("URL 为空")
+	}
+
+	// data: URI 的内容可能含有 url.Parse 不接受的字符，单独校验，只把协议名转为小写
+	if isDataURI(raw) {
+		if _, _, err := decodeDataURI(raw); err != nil {
+			return "", err
+		}
+		return "data:" + raw[len("data:"):], nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("URL 解析失败: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "ftp":
+	case "file":
+		// 本地文件只允许空主机名或 localhost
+		if u.Host != "" && u.Host != "localhost" {
+			return "", fmt.Errorf("不支持访问远程主机的文件: %s", u.Host)
+		}
+		if u.Path == "" {
+			return "", errors.New("URL 缺少文件路径")
+		}
+		return u.String(), nil
+	default:
+		return "", fmt.Errorf("不支持的协议: %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", errors.New("URL 缺少主机名")
+	}
+
+	// 未加方括号的 IPv6 地址会被 url.Parse 误拆成主机和端口，这里直接拒绝
+	if !strings.HasPrefix(u.Host, "[") && strings.Count(u.Host, ":") > 1 {
+		return "", fmt.Errorf("IPv6 地址需要使用方括号: %s", u.Host)
+	}
+
+	return u.String(), nil
+}
+
+// expandURL 未开启 ExpandEnv 时原样返回；开启时将 URL 中的 ${NAME} 或 $NAME 替换为变量值，
+// 先查 EnvVars，再查环境变量，$$ 表示字面的 $。替换的值原样插入、不做百分号编码，
+// 含空格等特殊字符的值需要事先编码。未定义的变量在 StrictEnv 下报错，否则替换为空
+func expandURL(raw string, opts Options) (string, error) {
+	if !opts.ExpandEnv {
+		return raw, nil
+	}
+
+	var missing []string
+	expanded := os.Expand(raw, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		if v, ok := opts.EnvVars[name]; ok {
+			return v
+		}
+		if v, ok := os.LookupEnv(name); ok {
+			return v
+		}
+		missing = append(missing, name)
+		return ""
+	})
+	if opts.StrictEnv && len(missing) > 0 {
+		return "", fmt.Errorf("URL 中的变量未定义: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// errHostFiltered 主机不在 AllowHosts 中或在 DenyHosts 中，没有发起请求
+var errHostFiltered = errors.New("主机被过滤")
+
+// hostAllowed 判断是否允许访问该主机：设置了 AllowHosts 时必须匹配其中之一，且不能匹配 DenyHosts。
+// 模式按 path.Match 匹配，不区分大小写，如 *.example.com 匹配 a.example.com，但不匹配 example.com
+func hostAllowed(host string, opts Options) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range opts.DenyHosts {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return false
+		}
+	}
+	if len(opts.AllowHosts) == 0 {
+		return true
+	}
+	for _, pattern := range opts.AllowHosts {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultPorts 各协议的默认端口，比较 URL 时省略
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ftp": "21"}
+
+// canonicalURL 返回用于比较的规范形式：协议和主机名转为小写，去掉主机名末尾的点和默认端口，
+// 空路径记为 /，去掉片段；sortQuery 为 true 时查询参数按名称排序。
+// 只用于判断两个 URL 是否指向同一资源，显示和请求仍使用原文。无法解析时返回去掉首尾空白的原文
+func canonicalURL(raw string, sortQuery bool) string {
+	raw = strings.TrimSpace(raw)
+	if isDataURI(raw) {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[u.Scheme] {
+		host += ":" + port
+	}
+	u.Host = host
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment, u.RawFragment = "", ""
+	if sortQuery && u.RawQuery != "" {
+		// Encode 按参数名排序，同名参数保持原有顺序
+		u.RawQuery = u.Query().Encode()
+	}
+	return u.String()
+}
+
+// inShard 判断 URL 是否分到本分片：按规范形式的 FNV-1a 哈希对分片总数取模，
+// 同一 URL 在不同机器、不同输入顺序下总是分到同一分片。未设置分片时总是返回 true
+func inShard(raw string, opts Options) bool {
+	if opts.ShardCount <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(canonicalURL(raw, opts.CanonicalSortQuery)))
+	return int(h.Sum32()%uint32(opts.ShardCount)) == opts.ShardIndex
+}
+
+// shardTargets 只保留分到本分片的目标，输入位置保持不变，便于合并后还原顺序
+func shardTargets(targets []Target, opts Options) []Target {
+	if opts.ShardCount <= 1 {
+		return targets
+	}
+	var kept []Target
+	for _, t := range targets {
+		if inShard(t.URL, opts) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// swapScheme 将 http 与 https 互换，其他协议返回 false
+func swapScheme(raw string) (string, bool) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "https"
+	case "https":
+		u.Scheme = "http"
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+// stdinPath 表示从标准输入读取 URL 列表的路径
+const stdinPath = "-"
+
+// loadURLsFromFile 从文本文件（可以是 gzip 压缩的）读取目标列表，每行一个，忽略空行和 # 开头的注释。
+// 以 { 开头的行按 JSON 解析为结构化目标，如 {"url": "https://example.com/a.zip", "timeout": "60s", "headers": {"Authorization": "Bearer xxx"}}。
+// path 为 - 时从标准输入读取
+func loadURLsFromFile(path string) ([]Target, error) {
+	var targets []Target
+	err := scanURLsFromFile(path, func(t Target) error {
+		targets = append(targets, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+// scanURLsFromFile 逐行读取 URL 列表并依次交给 fn，不在内存中保留整个列表，
+// 格式同 loadURLsFromFile。fn 返回错误时停止读取并原样返回该错误
+func scanURLsFromFile(path string, fn func(Target) error) error {
+	if path == stdinPath {
+		return scanURLs(os.Stdin, false, fn)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开 URL 列表失败: %w", err)
+	}
+	defer file.Close()
+	return scanURLs(file, strings.HasSuffix(path, ".gz"), fn)
+}
+
+// scanURLs 从 r 逐行读取 URL 列表，格式同 loadURLsFromFile。
+// gzipped 为 true 或内容以 gzip 魔数开头时透明解压
+func scanURLs(r io.Reader, gzipped bool, fn func(Target) error) error {
+	buffered := bufio.NewReader(r)
+	var reader io.Reader = buffered
+	if magic, _ := buffered.Peek(2); gzipped || bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("解压 URL 列表失败: %w", err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	scanner := bufio.NewScanner(reader)
+	index := 0
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		t := Target{URL: line}
+		if strings.HasPrefix(line, "{") {
+			t = Target{}
+			if err := json.Unmarshal([]byte(line), &t); err != nil {
+				return fmt.Errorf("第 %d 行解析失败: %w", lineNo, err)
+			}
+		}
+		t.index = index
+		index++
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取 URL 列表失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scanAll 读取 r 中的全部目标
+func scanAll(t *testing.T, src string) []Target {
+	t.Helper()
+	var targets []Target
+	err := scanURLs(strings.NewReader(src), false, func(target Target) error {
+		targets = append(targets, target)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return targets
+}
+
+func TestScanURLsReader(t *testing.T) {
+	src := "  http://example.com/a  \n" +
+		"\n" +
+		"# 注释\n" +
+		"\t \n" +
+		"   # 缩进的注释\n" +
+		"http://example.com/b\r\n" +
+		`{"url": "http://example.com/c", "timeout": "5s"}` + "\n" +
+		"http://example.com/d"
+	targets := scanAll(t, src)
+
+	var urls []string
+	for i, target := range targets {
+		urls = append(urls, target.URL)
+		if target.index != i {
+			t.Errorf("%s 的输入位置为 %d，应为 %d", target.URL, target.index, i)
+		}
+	}
+	want := []string{"http://example.com/a", "http://example.com/b", "http://example.com/c", "http://example.com/d"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Fatalf("URL = %q，应为 %q", urls, want)
+	}
+	if targets[2].Timeout != Duration(5*time.Second) {
+		t.Errorf("JSON 行的超时为 %v，应为 5s", targets[2].Timeout)
+	}
+}
+
+func TestScanURLsInvalidJSON(t *testing.T) {
+	err := scanURLs(strings.NewReader("http://example.com/a\n{\"url\": \n"), false, func(Target) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "第 2 行") {
+		t.Fatalf("err = %v，应指出第 2 行解析失败", err)
+	}
+}
+
+func TestLoadURLsFromFileGzip(t *testing.T) {
+	const list = "http://example.com/a\n# 注释\nhttp://example.com/b\n"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(list))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"urls.txt":    []byte(list),
+		"urls.txt.gz": gz.Bytes(),
+		"urls.bin":    gz.Bytes(), // 没有 .gz 扩展名时按 gzip 魔数识别
+	}
+	want := []string{"http://example.com/a", "http://example.com/b"}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		targets, err := loadURLsFromFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var urls []string
+		for _, target := range targets {
+			urls = append(urls, target.URL)
+		}
+		if !reflect.DeepEqual(urls, want) {
+			t.Errorf("%s: URL = %q，应为 %q", name, urls, want)
+		}
+	}
+}
+
+func TestNormalizeURLIPv6(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "http://[2001:db8::1]/file", want: "http://[2001:db8::1]/file"},
+		{in: "https://[2001:db8::1]:8443/file", want: "https://[2001:db8::1]:8443/file"},
+		{in: "  http://[::1]:8080/a.bin ", want: "http://[::1]:8080/a.bin"},
+		{in: "http://[fe80::1%25eth0]/file", want: "http://[fe80::1%25eth0]/file"},
+		{in: "http://[fe80::1%25eth0]:8080/file", want: "http://[fe80::1%25eth0]:8080/file"},
+		{in: "ftp://[2001:db8::2]/pub/file", want: "ftp://[2001:db8::2]/pub/file"},
+		{in: "http://2001:db8::1/file", wantErr: true},
+		{in: "http://[2001:db8::1/file", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeURL(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("normalizeURL(%q) = %q，应报错", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, %v，应为 %q", tt.in, got, err, tt.want)
+		}
+	}
+}
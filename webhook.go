@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookPayload 检查结束时 POST 给 WebhookURL 的内容
+type webhookPayload struct {
+	Status     string `json:"status"` // done 或 error
+	OutputPath string `json:"outputPath,omitempty"`
+	Stats      Stats  `json:"stats"`
+	Error      string `json:"error,omitempty"`
+	ErrorKind  string `json:"errorKind,omitempty"` // 同 ErrorEvent.Kind
+}
+
+// notifyWebhook 检查结束后通知 WebhookURL，未设置时不做任何事。
+// 通知失败只发出警告，不影响检查本身的结果
+func (a *App) notifyWebhook(opts Options, outputPath string, stats Stats, err error) {
+	if opts.WebhookURL == "" {
+		return
+	}
+
+	payload := webhookPayload{Status: "done", OutputPath: outputPath, Stats: stats}
+	if err != nil {
+		payload.Status = "error"
+		payload.Error = err.Error()
+		payload.ErrorKind = errorKind(err)
+	}
+	timeout := time.Duration(opts.WebhookTimeout)
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	if err := sendWebhook(opts.WebhookURL, timeout, payload); err != nil {
+		a.warn("Webhook 通知失败: " + err.Error())
+	}
+}
+
+// sendWebhook 发送一次通知，非 2xx 响应视为失败
+func sendWebhook(url string, timeout time.Duration, payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// slotKey 在 context 中保存当前请求占用的并发槽
+type slotKey struct{}
+
+// slot 一个 worker 占用的并发槽，下载大文件时可以临时多占。
+// 只属于创建它的 worker，只能由该 worker 中检查这个 URL 的 goroutine 调整和释放，不加锁；
+// 另起 goroutine 检查（如看门狗）时必须等它返回后才能 release
+type slot struct {
+	sem  *semaphore.Weighted
+	held int64
+}
+
+// withSlot 将并发槽放入 context，供读取响应体时按大小调整占用
+func withSlot(ctx context.Context, s *slot) context.Context {
+	return context.WithValue(ctx, slotKey{}, s)
+}
+
+// resize 将占用的槽数调整为 n。减少时立即释放；增加时先全部释放再整体申请，
+// 避免多个 worker 各占一部分互相等待而死锁。申请失败（ctx 结束）时不再占用任何槽
+func (s *slot) resize(ctx context.Context, n int64) error {
+	if n <= s.held {
+		s.sem.Release(s.held - n)
+		s.held = n
+		return nil
+	}
+	s.sem.Release(s.held)
+	s.held = 0
+	if err := s.sem.Acquire(ctx, n); err != nil {
+		return err
+	}
+	s.held = n
+	return nil
+}
+
+// release 释放占用的全部槽
+func (s *slot) release() {
+	s.sem.Release(s.held)
+	s.held = 0
+}
+
+// bodyWeight 按 Content-Length 计算下载响应体时应占用的槽数，范围为 1 到并发数
+func (c *checker) bodyWeight(contentLength int64) int64 {
+	unit := c.opts.SizeWeightBytes
+	if unit <= 0 || contentLength <= 0 {
+		return 1
+	}
+	return min(contentLength/unit+1, int64(c.opts.Concurrency))
+}
+
+// weighBody 下载响应体前按大小多占并发槽，返回的函数恢复为一个槽。
+// 没有开启加权或不在 worker 中（如库调用方直接使用 SizeChecker）时不做任何事
+func (c *checker) weighBody(ctx context.Context, contentLength int64) (func(), error) {
+	s, _ := ctx.Value(slotKey{}).(*slot)
+	weight := c.bodyWeight(contentLength)
+	if s == nil || weight <= 1 {
+		return func() {}, nil
+	}
+	if err := s.resize(ctx, weight); err != nil {
+		return nil, err
+	}
+	return func() { s.resize(ctx, 1) }, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// maxBodiesInFlight 模拟 workers 个 worker 同时下载 contentLength 字节的响应体，返回同时下载的最大数量
+func maxBodiesInFlight(t *testing.T, c *checker, workers int, contentLength int64) int64 {
+	t.Helper()
+	sem := semaphore.NewWeighted(int64(c.opts.Concurrency))
+	var active, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		if err := sem.Acquire(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+		s := &slot{sem: sem, held: 1}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.release()
+
+			restore, err := c.weighBody(withSlot(context.Background(), s), contentLength)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer restore()
+			n := active.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			active.Add(-1)
+		}()
+	}
+	wg.Wait()
+	return peak.Load()
+}
+
+func TestWeighBody(t *testing.T) {
+	c := &checker{opts: Options{Concurrency: 4, SizeWeightBytes: 1000}}
+
+	if peak := maxBodiesInFlight(t, c, 6, 10_000); peak != 1 {
+		t.Errorf("大文件同时下载 %d 个，应逐个下载", peak)
+	}
+	if peak := maxBodiesInFlight(t, c, 8, 10); peak != 4 {
+		t.Errorf("小文件同时下载 %d 个，应占满 4 个并发", peak)
+	}
+}
+
+func TestBodyWeight(t *testing.T) {
+	c := &checker{opts: Options{Concurrency: 4, SizeWeightBytes: 1000}}
+	tests := []struct {
+		contentLength int64
+		want          int64
+	}{
+		{-1, 1},
+		{0, 1},
+		{999, 1},
+		{1000, 2},
+		{2500, 3},
+		{1 << 30, 4},
+	}
+	for _, tt := range tests {
+		if got := c.bodyWeight(tt.contentLength); got != tt.want {
+			t.Errorf("bodyWeight(%d) = %d，应为 %d", tt.contentLength, got, tt.want)
+		}
+	}
+}